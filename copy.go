@@ -0,0 +1,286 @@
+package redmine
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+//-------------------------------------------------------------------------
+// project field mapping
+//-------------------------------------------------------------------------
+
+// projectMapper maps the tracker, category and version of an issue to
+// valid equivalents in a target project, matching them by name.
+type projectMapper struct {
+	project    *Project
+	versions   []*Version
+	categories []*IssueCategory
+}
+
+func (s *Service) newProjectMapper(projectId int) (*projectMapper, error) {
+	project, err := s.Projects.Get(ProjectId(projectId)).Trackers(true).Do()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.Versions.List(ProjectId(projectId)).Do()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.IssueCategories.List(ProjectId(projectId)).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &projectMapper{project, versions, categories}, nil
+}
+
+// tracker returns the tracker with the same id or name in the target
+// project, or its first tracker.
+func (m *projectMapper) tracker(tracker *Name) *Name {
+	if len(m.project.Trackers) == 0 {
+		return tracker
+	}
+	if tracker != nil {
+		for _, t := range m.project.Trackers {
+			if t.Id == tracker.Id {
+				return t
+			}
+		}
+		for _, t := range m.project.Trackers {
+			if strings.EqualFold(t.Name, tracker.Name) {
+				return t
+			}
+		}
+	}
+	return m.project.Trackers[0]
+}
+
+func (m *projectMapper) category(category *Name) *Name {
+	if category == nil {
+		return nil
+	}
+	for _, c := range m.categories {
+		if strings.EqualFold(c.Name, category.Name) {
+			return &Name{c.Id, c.Name}
+		}
+	}
+	return nil
+}
+
+// version returns the open version with the same id (shared versions) or
+// name in the target project.
+func (m *projectMapper) version(version *Name) *Name {
+	if version == nil {
+		return nil
+	}
+	for _, v := range m.versions {
+		if v.Status == "open" && v.Id == version.Id {
+			return &Name{v.Id, v.Name}
+		}
+	}
+	for _, v := range m.versions {
+		if v.Status == "open" && strings.EqualFold(v.Name, version.Name) {
+			return &Name{v.Id, v.Name}
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------
+// copy issue
+//-------------------------------------------------------------------------
+
+type IssueCopyOptions struct {
+	// Subtasks copies the whole subtask hierarchy below the issue.
+	Subtasks bool
+	// Relations recreates the relations of the copied issues.
+	Relations bool
+}
+
+type IssuesCopyCall struct {
+	s               *Service
+	issueId         int
+	targetProjectId int
+	opts            *IssueCopyOptions
+}
+
+// Copy duplicates an issue into a project with its subject, description,
+// custom fields, attachments and watchers. The tracker, category and
+// version are mapped to the ones of the target project.
+func (r *IssuesService) Copy(issueId, targetProjectId int, opts *IssueCopyOptions) *IssuesCopyCall {
+	if opts == nil {
+		opts = new(IssueCopyOptions)
+	}
+	return &IssuesCopyCall{r.s, issueId, targetProjectId, opts}
+}
+
+// Do returns the copy of the issue. The relations are recreated once all
+// the issues are copied, so that a relation between two copied issues
+// links the two copies; the ends outside the copied issues are kept.
+func (c *IssuesCopyCall) Do() (*Issue, error) {
+	m, err := c.s.newProjectMapper(c.targetProjectId)
+	if err != nil {
+		return nil, err
+	}
+	copies := make(map[int]int)
+	var sources []*Issue
+	dst, err := c.copy(m, c.issueId, 0, copies, &sources)
+	if err != nil {
+		return nil, err
+	}
+	if !c.opts.Relations {
+		return dst, nil
+	}
+	remap := func(id int) int {
+		if copyId, ok := copies[id]; ok {
+			return copyId
+		}
+		return id
+	}
+	done := make(map[int]bool)
+	for _, src := range sources {
+		for _, rel := range src.Relations {
+			if done[rel.Id] {
+				continue
+			}
+			done[rel.Id] = true
+			_, err = c.s.Relations.Insert(&Relation{
+				IssueId:      remap(rel.IssueId),
+				IssueToId:    remap(rel.IssueToId),
+				RelationType: rel.RelationType,
+				Delay:        int(rel.Delay),
+			}).Do()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dst, nil
+}
+
+// copy copies an issue and, with Subtasks, its descendants, recording the
+// copied issues and the ids of their copies.
+func (c *IssuesCopyCall) copy(m *projectMapper, issueId, parentId int, copies map[int]int, sources *[]*Issue) (*Issue, error) {
+	src, err := c.s.Issues.Get(issueId).
+		Attachments(true).
+		Children(c.opts.Subtasks).
+		Relations(c.opts.Relations).
+		Watchers(true).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+	uploads := make([]*Upload, 0, len(src.Attachments))
+	for _, a := range src.Attachments {
+		upload, err := c.reupload(a)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	dst := &Issue{
+		Subject:        src.Subject,
+		Description:    src.Description,
+		Project:        &Name{Id: c.targetProjectId},
+		Tracker:        m.tracker(src.Tracker),
+		Priority:       src.Priority,
+		Category:       m.category(src.Category),
+		FixedVersion:   m.version(src.FixedVersion),
+		StartDate:      src.StartDate,
+		DueDate:        src.DueDate,
+		EstimatedHours: src.EstimatedHours,
+		CustomFields:   src.CustomFields,
+		Watchers:       src.Watchers,
+	}
+	if parentId > 0 {
+		dst.Parent = &Id{parentId}
+	}
+	dst, err = c.s.Issues.Insert(dst, uploads...).Do()
+	if err != nil {
+		return nil, err
+	}
+	copies[src.Id] = dst.Id
+	*sources = append(*sources, src)
+	if c.opts.Subtasks {
+		for _, child := range src.Children {
+			_, err = c.copy(m, child.Id, dst.Id, copies, sources)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dst, nil
+}
+
+func (c *IssuesCopyCall) reupload(a *Attachment) (*Upload, error) {
+	rc, err := c.s.Attachments.Download(a.Id).Do()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	upload, err := c.s.Uploads.UploadReader(rc, int64(a.Filesize)).Filename(a.Filename).Do()
+	if err != nil {
+		return nil, err
+	}
+	upload.Description = a.Description
+	if a.ContentType != "" {
+		upload.ContentType = a.ContentType
+	}
+	return upload, nil
+}
+
+//-------------------------------------------------------------------------
+// move issue
+//-------------------------------------------------------------------------
+
+type IssuesMoveCall struct {
+	s               *Service
+	issueId         int
+	targetProjectId int
+}
+
+// Move moves an issue to another project, remapping its tracker, category
+// and version to valid equivalents in the target project. The category
+// and version are cleared when the target project has no equivalent.
+func (r *IssuesService) Move(issueId, targetProjectId int) *IssuesMoveCall {
+	return &IssuesMoveCall{r.s, issueId, targetProjectId}
+}
+
+func (c *IssuesMoveCall) Do() error {
+	src, err := c.s.Issues.Get(c.issueId).Do()
+	if err != nil {
+		return err
+	}
+	m, err := c.s.newProjectMapper(c.targetProjectId)
+	if err != nil {
+		return err
+	}
+	v := struct {
+		Issue map[string]interface{} `json:"issue"`
+	}{
+		map[string]interface{}{
+			"project_id":       c.targetProjectId,
+			"category_id":      "",
+			"fixed_version_id": "",
+		},
+	}
+	if tracker := m.tracker(src.Tracker); tracker != nil {
+		v.Issue["tracker_id"] = tracker.Id
+	}
+	if category := m.category(src.Category); category != nil {
+		v.Issue["category_id"] = category.Id
+	}
+	if version := m.version(src.FixedVersion); version != nil {
+		v.Issue["fixed_version_id"] = version.Id
+	}
+	body := new(bytes.Buffer)
+	err = json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "issues/{issueId}.json")
+	urlStr = strings.Replace(urlStr, "{issueId}", strconv.Itoa(c.issueId), 1)
+	_, err = c.s.doRequest("PUT", urlStr, body)
+	return err
+}
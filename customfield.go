@@ -0,0 +1,162 @@
+package redmine
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+//-------------------------------------------------------------------------
+// typed custom field values
+//-------------------------------------------------------------------------
+
+func (r *CustomField) Bool() (bool, error) {
+	switch r.Value {
+	case "1", "true":
+		return true, nil
+	case "0", "false", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("custom field %v: invalid bool %q", r.Id, r.Value)
+}
+
+func (r *CustomField) Int() (int, error) {
+	return strconv.Atoi(r.Value)
+}
+
+func (r *CustomField) Float() (float64, error) {
+	return strconv.ParseFloat(r.Value, 64)
+}
+
+func (r *CustomField) Date() (time.Time, error) {
+	return time.Parse(DateLayout, r.Value)
+}
+
+// Strings returns the values of the field, whether it is a multi-value
+// field or not.
+func (r *CustomField) Strings() []string {
+	if r.Multiple {
+		return r.Values
+	}
+	if r.Value == "" {
+		return nil
+	}
+	return []string{r.Value}
+}
+
+//-------------------------------------------------------------------------
+// custom field validation
+//-------------------------------------------------------------------------
+
+// Validate checks that the value of cf is acceptable for the definition.
+func (d *CustomFieldDefinition) Validate(cf *CustomField) error {
+	values := cf.Strings()
+	if len(values) == 0 {
+		if d.IsRequired {
+			return fmt.Errorf("custom field %v: value is required", d.Name)
+		}
+		return nil
+	}
+	if len(values) > 1 && !d.Multiple {
+		return fmt.Errorf("custom field %v: multiple values are not allowed", d.Name)
+	}
+	// the regexp is a ruby one; the ones go cannot compile, such as those
+	// with lookarounds or backreferences, are left to redmine to check
+	var re *regexp.Regexp
+	if d.Regexp != "" {
+		re, _ = regexp.Compile(d.Regexp)
+	}
+	for _, value := range values {
+		err := d.validateValue(value, re)
+		if err != nil {
+			return fmt.Errorf("custom field %v: %v", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateValue checks a single value. Lengths are counted in characters,
+// as redmine does.
+func (d *CustomFieldDefinition) validateValue(value string, re *regexp.Regexp) error {
+	length := utf8.RuneCountInString(value)
+	if d.MinLength > 0 && length < int(d.MinLength) {
+		return fmt.Errorf("%q is shorter than %v", value, d.MinLength)
+	}
+	if d.MaxLength > 0 && length > int(d.MaxLength) {
+		return fmt.Errorf("%q is longer than %v", value, d.MaxLength)
+	}
+	if re != nil && !re.MatchString(value) {
+		return fmt.Errorf("%q does not match %v", value, d.Regexp)
+	}
+	switch d.FieldFormat {
+	case "int", "user", "version", "enumeration":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+	case "float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case "date":
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return fmt.Errorf("%q is not a date", value)
+		}
+	case "bool":
+		if value != "0" && value != "1" {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	}
+	if d.PossibleValues != nil && (d.FieldFormat == "list" || d.FieldFormat == "enumeration" ||
+		d.FieldFormat == "user" || d.FieldFormat == "version") {
+		for _, possible := range d.PossibleValues {
+			if possible.Value == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not a possible value", value)
+	}
+	return nil
+}
+
+// Value returns a validated custom field holding the given values, ready
+// to be sent with an issue, project, user, time entry, version or group.
+func (d *CustomFieldDefinition) Value(values ...string) (*CustomField, error) {
+	cf := &CustomField{
+		Id:       d.Id,
+		Name:     d.Name,
+		Multiple: d.Multiple,
+	}
+	if d.Multiple {
+		cf.Values = values
+	} else if len(values) == 1 {
+		cf.Value = values[0]
+	} else if len(values) > 1 {
+		return nil, fmt.Errorf("custom field %v: multiple values are not allowed", d.Name)
+	}
+	err := d.Validate(cf)
+	if err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+func (d *CustomFieldDefinition) BoolValue(b bool) (*CustomField, error) {
+	if b {
+		return d.Value("1")
+	}
+	return d.Value("0")
+}
+
+func (d *CustomFieldDefinition) IntValue(i int) (*CustomField, error) {
+	return d.Value(strconv.Itoa(i))
+}
+
+func (d *CustomFieldDefinition) FloatValue(f float64) (*CustomField, error) {
+	return d.Value(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func (d *CustomFieldDefinition) DateValue(t time.Time) (*CustomField, error) {
+	return d.Value(t.Format(DateLayout))
+}
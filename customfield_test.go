@@ -0,0 +1,25 @@
+package redmine
+
+import "testing"
+
+func TestValidateLength(t *testing.T) {
+	d := &CustomFieldDefinition{Name: "Code", FieldFormat: "string", MinLength: 2, MaxLength: 4}
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"ab", true},
+		{"äöüß", true},
+		{"日本語", true},
+		{"a", false},
+		{"é", false},
+		{"abcde", false},
+		{"äöüßé", false},
+	}
+	for _, tt := range tests {
+		err := d.Validate(&CustomField{Value: tt.value})
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%q) = %v, want ok %v", tt.value, err, tt.ok)
+		}
+	}
+}
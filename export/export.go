@@ -0,0 +1,188 @@
+// Package export writes issue lists as CSV, JSON Lines or XLSX. The issues
+// are written page by page, so large lists are never held in memory.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/woli/redmine"
+)
+
+// Column is a column of the export. Value returns a string, an int, a
+// float64 or nil for an empty cell.
+type Column struct {
+	Name  string
+	Value func(issue *redmine.Issue) interface{}
+	// include names the data the column needs besides the list fields.
+	include string
+}
+
+const (
+	includeRelations = "relations"
+	includeJournals  = "journals"
+)
+
+func name(n *redmine.Name) interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.Name
+}
+
+func str(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+var (
+	Id          = Column{Name: "#", Value: func(i *redmine.Issue) interface{} { return i.Id }}
+	Project     = Column{Name: "Project", Value: func(i *redmine.Issue) interface{} { return name(i.Project) }}
+	Tracker     = Column{Name: "Tracker", Value: func(i *redmine.Issue) interface{} { return name(i.Tracker) }}
+	Status      = Column{Name: "Status", Value: func(i *redmine.Issue) interface{} { return name(i.Status) }}
+	Priority    = Column{Name: "Priority", Value: func(i *redmine.Issue) interface{} { return name(i.Priority) }}
+	Subject     = Column{Name: "Subject", Value: func(i *redmine.Issue) interface{} { return i.Subject }}
+	Author      = Column{Name: "Author", Value: func(i *redmine.Issue) interface{} { return name(i.Author) }}
+	AssignedTo  = Column{Name: "Assignee", Value: func(i *redmine.Issue) interface{} { return name(i.AssignedTo) }}
+	Category    = Column{Name: "Category", Value: func(i *redmine.Issue) interface{} { return name(i.Category) }}
+	Version     = Column{Name: "Target version", Value: func(i *redmine.Issue) interface{} { return name(i.FixedVersion) }}
+	StartDate   = Column{Name: "Start date", Value: func(i *redmine.Issue) interface{} { return str(i.StartDate) }}
+	DueDate     = Column{Name: "Due date", Value: func(i *redmine.Issue) interface{} { return str(i.DueDate) }}
+	DoneRatio   = Column{Name: "% Done", Value: func(i *redmine.Issue) interface{} { return i.DoneRatio }}
+	Estimated   = Column{Name: "Estimated time", Value: func(i *redmine.Issue) interface{} { return i.EstimatedHours }}
+	Spent       = Column{Name: "Spent time", Value: func(i *redmine.Issue) interface{} { return i.SpentHours }}
+	CreatedOn   = Column{Name: "Created", Value: func(i *redmine.Issue) interface{} { return str(i.CreatedOn) }}
+	UpdatedOn   = Column{Name: "Updated", Value: func(i *redmine.Issue) interface{} { return str(i.UpdatedOn) }}
+	Description = Column{Name: "Description", Value: func(i *redmine.Issue) interface{} { return str(i.Description) }}
+	Parent      = Column{Name: "Parent task", Value: func(i *redmine.Issue) interface{} {
+		if i.Parent == nil {
+			return nil
+		}
+		return i.Parent.Id
+	}}
+)
+
+// DefaultColumns are the columns of the issue list of redmine.
+var DefaultColumns = []Column{Id, Project, Tracker, Status, Priority, Subject, AssignedTo, UpdatedOn}
+
+// CustomField is the column of the named custom field. The values of
+// multi-value fields are joined with commas.
+func CustomField(name string) Column {
+	return Column{Name: name, Value: func(i *redmine.Issue) interface{} {
+		for _, cf := range i.CustomFields {
+			if cf.Name == name {
+				return str(strings.Join(cf.Strings(), ", "))
+			}
+		}
+		return nil
+	}}
+}
+
+// Journals counts the notes and changes of each issue. The journals are
+// not part of the issue list, so each issue is fetched again.
+var Journals = Column{Name: "Journals", include: includeJournals, Value: func(i *redmine.Issue) interface{} {
+	return len(i.Journals)
+}}
+
+// Relations summarizes the relations of each issue, e.g.
+// "blocks #12, follows #7 (2 days)".
+var Relations = Column{Name: "Related issues", include: includeRelations, Value: func(i *redmine.Issue) interface{} {
+	var parts []string
+	for _, rel := range i.Relations {
+		label, other := relationLabels[rel.RelationType], rel.IssueToId
+		if rel.IssueToId == i.Id {
+			label, other = reverseLabels[rel.RelationType], rel.IssueId
+		}
+		if label == "" {
+			label = rel.RelationType
+		}
+		part := fmt.Sprintf("%v #%v", label, other)
+		if rel.Delay != 0 {
+			part += fmt.Sprintf(" (%v days)", int(rel.Delay))
+		}
+		parts = append(parts, part)
+	}
+	return str(strings.Join(parts, ", "))
+}}
+
+var relationLabels = map[string]string{
+	"relates":    "related to",
+	"duplicates": "is duplicate of",
+	"blocks":     "blocks",
+	"precedes":   "precedes",
+	"copied_to":  "copied to",
+}
+
+var reverseLabels = map[string]string{
+	"relates":    "related to",
+	"duplicates": "has duplicate",
+	"blocks":     "blocked by",
+	"precedes":   "follows",
+	"copied_to":  "copied from",
+}
+
+//-------------------------------------------------------------------------
+// export
+//-------------------------------------------------------------------------
+
+// Writer writes the rows of an export in a file format.
+type Writer interface {
+	// Header is called once, before the rows.
+	Header(names []string) error
+	Row(values []interface{}) error
+	// Close flushes the output; it does not close the underlying writer.
+	Close() error
+}
+
+// Export writes the issues matching list with the given columns, a page
+// at a time. w is closed on every path, so that on error it still holds a
+// well-formed file with the rows written so far; the first error is
+// returned.
+func Export(s *redmine.Service, list *redmine.IssuesListCall, columns []Column, w Writer) (err error) {
+	defer func() {
+		closeErr := w.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	names := make([]string, len(columns))
+	journals := false
+	for i, column := range columns {
+		names[i] = column.Name
+		switch column.include {
+		case includeRelations:
+			list.Relations(true)
+		case includeJournals:
+			journals = true
+		}
+	}
+	err = w.Header(names)
+	if err != nil {
+		return err
+	}
+	return list.Pages(func(feed *redmine.IssueFeed) error {
+		for _, issue := range feed.Issues {
+			if journals {
+				full, err := s.Issues.Get(issue.Id).Journals(true).Do()
+				if err != nil {
+					return err
+				}
+				issue.Journals = full.Journals
+			}
+			values := make([]interface{}, len(columns))
+			for i, column := range columns {
+				values[i] = column.Value(issue)
+			}
+			err := w.Row(values)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// cell formats a value for the text formats.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+//-------------------------------------------------------------------------
+// csv
+//-------------------------------------------------------------------------
+
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) Header(names []string) error {
+	return c.w.Write(names)
+}
+
+func (c *CSVWriter) Row(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cell(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+//-------------------------------------------------------------------------
+// json lines
+//-------------------------------------------------------------------------
+
+// JSONLWriter writes one JSON object per issue, keyed by column name in
+// column order.
+type JSONLWriter struct {
+	w     *bufio.Writer
+	names [][]byte
+}
+
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: bufio.NewWriter(w)}
+}
+
+func (c *JSONLWriter) Header(names []string) error {
+	c.names = make([][]byte, len(names))
+	for i, name := range names {
+		data, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		c.names[i] = data
+	}
+	return nil
+}
+
+func (c *JSONLWriter) Row(values []interface{}) error {
+	b := new(bytes.Buffer)
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(c.names[i])
+		b.WriteByte(':')
+		b.Write(data)
+	}
+	b.WriteString("}\n")
+	_, err := c.w.Write(b.Bytes())
+	return err
+}
+
+func (c *JSONLWriter) Close() error {
+	return c.w.Flush()
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+//-------------------------------------------------------------------------
+// xlsx
+//-------------------------------------------------------------------------
+
+// XLSXWriter writes a workbook with a single sheet. Strings are written
+// inline, without a shared strings table, so that rows can be streamed.
+type XLSXWriter struct {
+	z   *zip.Writer
+	w   *bufio.Writer
+	row int
+}
+
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{z: zip.NewWriter(w)}
+}
+
+// excel refuses cells longer than this
+const xlsxMaxCell = 32767
+
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Issues" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// style 1 is the bold header
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// Header writes the fixed parts of the workbook and starts the sheet with
+// a frozen header row.
+func (c *XLSXWriter) Header(names []string) error {
+	for _, part := range xlsxParts {
+		f, err := c.z.Create(part.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, part.content)
+		if err != nil {
+			return err
+		}
+	}
+	f, err := c.z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	c.w = bufio.NewWriter(f)
+	c.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`</sheetView></sheetViews><sheetData>`)
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = name
+	}
+	return c.writeRow(values, 1)
+}
+
+func (c *XLSXWriter) Row(values []interface{}) error {
+	return c.writeRow(values, 0)
+}
+
+func (c *XLSXWriter) writeRow(values []interface{}, style int) error {
+	c.row++
+	fmt.Fprintf(c.w, `<row r="%d">`, c.row)
+	for i, v := range values {
+		ref := xlsxColumn(i) + strconv.Itoa(c.row)
+		s := ""
+		if style != 0 {
+			s = fmt.Sprintf(` s="%d"`, style)
+		}
+		switch v := v.(type) {
+		case nil:
+			continue
+		case int:
+			fmt.Fprintf(c.w, `<c r="%v"%v><v>%d</v></c>`, ref, s, v)
+		case float64:
+			fmt.Fprintf(c.w, `<c r="%v"%v><v>%v</v></c>`, ref, s, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			text := []rune(cell(v))
+			if len(text) > xlsxMaxCell {
+				text = text[:xlsxMaxCell]
+			}
+			fmt.Fprintf(c.w, `<c r="%v"%v t="inlineStr"><is><t xml:space="preserve">`, ref, s)
+			xml.EscapeText(c.w, []byte(string(text)))
+			c.w.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := c.w.WriteString(`</row>`)
+	return err
+}
+
+// xlsxColumn returns the letters of the zero based column i.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func (c *XLSXWriter) Close() error {
+	if c.w != nil {
+		c.w.WriteString(`</sheetData></worksheet>`)
+		err := c.w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	return c.z.Close()
+}
@@ -0,0 +1,258 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//-------------------------------------------------------------------------
+// export
+//-------------------------------------------------------------------------
+
+type ExportOptions struct {
+	// CollapseClosed leaves the closed issues and their relations out.
+	CollapseClosed bool
+	// Parents adds an edge from each parent issue to its subtasks.
+	Parents bool
+	// TrackerColors and StatusColors map tracker and status names to
+	// colors such as "#d62728". Unlisted names get the next color of a
+	// default palette, in name order.
+	TrackerColors map[string]string
+	StatusColors  map[string]string
+}
+
+var palette = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+var statusPalette = []string{
+	"#aec7e8", "#ffbb78", "#98df8a", "#ff9896", "#c5b0d5",
+	"#c49c94", "#f7b6d2", "#c7c7c7", "#dbdb8d", "#9edae5",
+}
+
+// colorize assigns a color to each name, sorted.
+func colorize(colors map[string]string, defaults []string, names map[string]bool) ([]string, map[string]string) {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	assigned := make(map[string]string)
+	i := 0
+	for _, name := range sorted {
+		if c, ok := colors[name]; ok {
+			assigned[name] = c
+			continue
+		}
+		assigned[name] = defaults[i%len(defaults)]
+		i++
+	}
+	return sorted, assigned
+}
+
+func names(nodes []*exportNode, name func(n *exportNode) string) map[string]bool {
+	set := make(map[string]bool)
+	for _, n := range nodes {
+		if name(n) != "" {
+			set[name(n)] = true
+		}
+	}
+	return set
+}
+
+func statusName(n *exportNode) string {
+	return n.status
+}
+
+func trackerName(n *exportNode) string {
+	return n.tracker
+}
+
+type exportNode struct {
+	id      int
+	label   string
+	tracker string
+	status  string
+}
+
+type exportEdge struct {
+	from int
+	to   int
+	kind string
+}
+
+func (g *Graph) exportGraph(opts *ExportOptions) ([]*exportNode, []*exportEdge) {
+	hidden := func(id int) bool {
+		return opts.CollapseClosed && g.Closed[id]
+	}
+	var nodes []*exportNode
+	for _, id := range g.nodes() {
+		if hidden(id) {
+			continue
+		}
+		n := &exportNode{id: id, label: fmt.Sprintf("#%v", id)}
+		if issue, ok := g.Issues[id]; ok {
+			if issue.Tracker != nil {
+				n.tracker = issue.Tracker.Name
+				n.label += " " + issue.Tracker.Name
+			}
+			n.label += ": " + issue.Subject
+			if issue.Status != nil {
+				n.status = issue.Status.Name
+				n.label += "\n(" + issue.Status.Name + ")"
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	var edges []*exportEdge
+	for _, e := range g.Edges {
+		if hidden(e.From) || hidden(e.To) {
+			continue
+		}
+		edges = append(edges, &exportEdge{e.From, e.To, e.Type})
+	}
+	if opts.Parents {
+		for _, issue := range g.Issues {
+			if issue.Parent == nil || hidden(issue.Id) || hidden(issue.Parent.Id) {
+				continue
+			}
+			if _, ok := g.Issues[issue.Parent.Id]; !ok {
+				continue
+			}
+			edges = append(edges, &exportEdge{issue.Parent.Id, issue.Id, "parent"})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		if edges[i].to != edges[j].to {
+			return edges[i].to < edges[j].to
+		}
+		return edges[i].kind < edges[j].kind
+	})
+	return nodes, edges
+}
+
+//-------------------------------------------------------------------------
+// graphviz
+//-------------------------------------------------------------------------
+
+var dotEdgeStyles = map[string]string{
+	"blocks":     `color="#d62728", penwidth=2`,
+	"precedes":   `color="#1f77b4"`,
+	"relates":    `style=dashed, dir=none, color="#7f7f7f"`,
+	"duplicates": `style=dotted, color="#9467bd"`,
+	"copied_to":  `style=dotted, color="#7f7f7f"`,
+	"parent":     `style=dashed, arrowhead=empty, color="#7f7f7f"`,
+}
+
+// WriteDOT renders the graph in the Graphviz DOT language. Nodes are
+// filled with the color of their status and outlined with the color of
+// their tracker.
+func (g *Graph) WriteDOT(w io.Writer, opts *ExportOptions) error {
+	if opts == nil {
+		opts = new(ExportOptions)
+	}
+	nodes, edges := g.exportGraph(opts)
+	_, statusColors := colorize(opts.StatusColors, statusPalette, names(nodes, statusName))
+	_, trackerColors := colorize(opts.TrackerColors, palette, names(nodes, trackerName))
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph relations {")
+	fmt.Fprintln(b, "\trankdir=LR;")
+	fmt.Fprintln(b, "\tnode [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];")
+	for _, n := range nodes {
+		attrs := fmt.Sprintf("label=%v", dotQuote(n.label))
+		if n.status != "" {
+			attrs += fmt.Sprintf(", fillcolor=%q", statusColors[n.status])
+		}
+		if n.tracker != "" {
+			attrs += fmt.Sprintf(", color=%q, penwidth=2", trackerColors[n.tracker])
+		}
+		fmt.Fprintf(b, "\t%v [%v];\n", n.id, attrs)
+	}
+	for _, e := range edges {
+		style, ok := dotEdgeStyles[e.kind]
+		if !ok {
+			style = "style=solid"
+		}
+		fmt.Fprintf(b, "\t%v -> %v [label=%v, %v];\n", e.from, e.to, dotQuote(e.kind), style)
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
+
+//-------------------------------------------------------------------------
+// mermaid
+//-------------------------------------------------------------------------
+
+var mermaidArrows = map[string]string{
+	"blocks":     "==>",
+	"precedes":   "-->",
+	"relates":    "---",
+	"duplicates": "-.->",
+	"copied_to":  "-.->",
+	"parent":     "-.->",
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart. Nodes get one
+// class per status (fill) and tracker (stroke).
+func (g *Graph) WriteMermaid(w io.Writer, opts *ExportOptions) error {
+	if opts == nil {
+		opts = new(ExportOptions)
+	}
+	nodes, edges := g.exportGraph(opts)
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "flowchart LR")
+	statuses := make(map[string][]string)
+	trackers := make(map[string][]string)
+	for _, n := range nodes {
+		id := fmt.Sprintf("i%v", n.id)
+		fmt.Fprintf(b, "    %v[\"%v\"]\n", id, mermaidEscape(n.label))
+		if n.status != "" {
+			statuses[n.status] = append(statuses[n.status], id)
+		}
+		if n.tracker != "" {
+			trackers[n.tracker] = append(trackers[n.tracker], id)
+		}
+	}
+	for _, e := range edges {
+		arrow, ok := mermaidArrows[e.kind]
+		if !ok {
+			arrow = "-->"
+		}
+		fmt.Fprintf(b, "    i%v %v|%v| i%v\n", e.from, arrow, e.kind, e.to)
+	}
+	writeClasses := func(prefix, property string, colors map[string]string, defaults []string, members map[string][]string) {
+		set := make(map[string]bool)
+		for name := range members {
+			set[name] = true
+		}
+		sorted, assigned := colorize(colors, defaults, set)
+		for i, name := range sorted {
+			class := fmt.Sprintf("%v%v", prefix, i)
+			fmt.Fprintf(b, "    classDef %v %v:%v\n", class, property, assigned[name])
+			fmt.Fprintf(b, "    class %v %v\n", strings.Join(members[name], ","), class)
+		}
+	}
+	writeClasses("status", "fill", opts.StatusColors, statusPalette, statuses)
+	writeClasses("tracker", "stroke-width:2px,stroke", opts.TrackerColors, palette, trackers)
+	return b.Flush()
+}
+
+func mermaidEscape(s string) string {
+	s = strings.Replace(s, `"`, "#quot;", -1)
+	s = strings.Replace(s, "\n", "<br/>", -1)
+	return s
+}
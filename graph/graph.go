@@ -0,0 +1,413 @@
+// Package graph analyses the relations between Redmine issues: precedes and
+// blocks cycles, transitive blockers and critical paths.
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/woli/redmine"
+)
+
+// Edge is a relation between two issues. Reverse relation types (blocked,
+// follows, duplicated, copied_from) are normalized so that From blocks,
+// precedes, duplicates or was copied to To.
+type Edge struct {
+	Id    int
+	From  int
+	To    int
+	Type  string
+	Delay int
+}
+
+// Gating tells whether To cannot be done before From.
+func (e *Edge) Gating() bool {
+	return e.Type == "blocks" || e.Type == "precedes"
+}
+
+type Graph struct {
+	Issues map[int]*redmine.Issue
+	Closed map[int]bool
+	Edges  []*Edge
+	out    map[int][]*Edge
+	in     map[int][]*Edge
+	seen   map[int]bool
+}
+
+var reverseTypes = map[string]string{
+	"blocked":     "blocks",
+	"follows":     "precedes",
+	"duplicated":  "duplicates",
+	"copied_from": "copied_to",
+}
+
+// New builds a graph from issues whose relations are loaded. closedStatuses
+// tells which issue statuses are closed and may be nil.
+func New(issues []*redmine.Issue, closedStatuses map[int]bool) *Graph {
+	g := &Graph{
+		Issues: make(map[int]*redmine.Issue),
+		Closed: make(map[int]bool),
+		out:    make(map[int][]*Edge),
+		in:     make(map[int][]*Edge),
+		seen:   make(map[int]bool),
+	}
+	for _, issue := range issues {
+		g.add(issue, closedStatuses)
+	}
+	return g
+}
+
+func (g *Graph) add(issue *redmine.Issue, closedStatuses map[int]bool) {
+	g.Issues[issue.Id] = issue
+	if issue.Status != nil && closedStatuses[issue.Status.Id] {
+		g.Closed[issue.Id] = true
+	}
+	for _, rel := range issue.Relations {
+		if g.seen[rel.Id] {
+			continue
+		}
+		g.seen[rel.Id] = true
+		e := &Edge{
+			Id:    rel.Id,
+			From:  rel.IssueId,
+			To:    rel.IssueToId,
+			Type:  rel.RelationType,
+			Delay: int(rel.Delay),
+		}
+		if t, ok := reverseTypes[e.Type]; ok {
+			e.From, e.To, e.Type = e.To, e.From, t
+		}
+		g.Edges = append(g.Edges, e)
+		g.out[e.From] = append(g.out[e.From], e)
+		g.in[e.To] = append(g.in[e.To], e)
+	}
+}
+
+// Out returns the relations starting at an issue.
+func (g *Graph) Out(issueId int) []*Edge {
+	return g.out[issueId]
+}
+
+// In returns the relations ending at an issue.
+func (g *Graph) In(issueId int) []*Edge {
+	return g.in[issueId]
+}
+
+//-------------------------------------------------------------------------
+// crawl
+//-------------------------------------------------------------------------
+
+type Scope struct {
+	// Project and VersionId select the issues to start from; both may be
+	// set.
+	Project   redmine.ProjectRef
+	VersionId int
+	// Follow fetches the issues outside the scope that the relations point
+	// to, transitively.
+	Follow bool
+}
+
+// Crawl lists the issues of a project or version with their relations and
+// builds their graph.
+func Crawl(s *redmine.Service, scope Scope) (*Graph, error) {
+	if scope.Project == nil && scope.VersionId == 0 {
+		return nil, errors.New("scope needs a project or a version")
+	}
+	statuses, err := s.IssueStatuses.List().Do()
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[int]bool)
+	for _, status := range statuses {
+		closed[status.Id] = status.IsClosed
+	}
+	call := s.Issues.List().Relations(true).Filter("status_id", "*")
+	if scope.Project != nil {
+		call.Filter("project_id", scope.Project.String())
+	}
+	if scope.VersionId > 0 {
+		call.Filter("fixed_version_id", strconv.Itoa(scope.VersionId))
+	}
+	var issues []*redmine.Issue
+	err = call.Pages(func(feed *redmine.IssueFeed) error {
+		issues = append(issues, feed.Issues...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	g := New(issues, closed)
+	if !scope.Follow {
+		return g, nil
+	}
+	for {
+		var missing []int
+		for _, e := range g.Edges {
+			for _, id := range []int{e.From, e.To} {
+				if _, ok := g.Issues[id]; !ok {
+					missing = append(missing, id)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return g, nil
+		}
+		for _, id := range missing {
+			if _, ok := g.Issues[id]; ok {
+				continue
+			}
+			issue, err := s.Issues.Get(id).Relations(true).Do()
+			if err != nil {
+				return nil, err
+			}
+			g.add(issue, closed)
+		}
+	}
+}
+
+//-------------------------------------------------------------------------
+// cycles
+//-------------------------------------------------------------------------
+
+// Cycles returns the groups of issues that block or precede each other in a
+// loop, each group sorted by id.
+func (g *Graph) Cycles() [][]int {
+	// Tarjan's strongly connected components over the gating edges
+	index := make(map[int]int)
+	low := make(map[int]int)
+	onStack := make(map[int]bool)
+	var stack []int
+	var cycles [][]int
+	next := 0
+	var visit func(v int)
+	visit = func(v int) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		selfLoop := false
+		for _, e := range g.out[v] {
+			if !e.Gating() {
+				continue
+			}
+			if e.To == v {
+				selfLoop = true
+			}
+			if _, ok := index[e.To]; !ok {
+				visit(e.To)
+				if low[e.To] < low[v] {
+					low[v] = low[e.To]
+				}
+			} else if onStack[e.To] && index[e.To] < low[v] {
+				low[v] = index[e.To]
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var component []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sort.Ints(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, v := range g.nodes() {
+		if _, ok := index[v]; !ok {
+			visit(v)
+		}
+	}
+	return cycles
+}
+
+// nodes returns the ids of the issues and relation ends in ascending order.
+func (g *Graph) nodes() []int {
+	set := make(map[int]bool)
+	for id := range g.Issues {
+		set[id] = true
+	}
+	for _, e := range g.Edges {
+		set[e.From] = true
+		set[e.To] = true
+	}
+	ids := make([]int, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+//-------------------------------------------------------------------------
+// blockers
+//-------------------------------------------------------------------------
+
+// Blockers returns the ids of the issues that transitively block or
+// precede an issue, sorted.
+func (g *Graph) Blockers(issueId int) []int {
+	var ids []int
+	seen := map[int]bool{issueId: true}
+	queue := []int{issueId}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, e := range g.in[v] {
+			if !e.Gating() || seen[e.From] {
+				continue
+			}
+			seen[e.From] = true
+			ids = append(ids, e.From)
+			queue = append(queue, e.From)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// OpenBlockers is like Blockers but only returns the issues that are not
+// closed. Closed blockers are still traversed.
+func (g *Graph) OpenBlockers(issueId int) []int {
+	var ids []int
+	for _, id := range g.Blockers(issueId) {
+		if !g.Closed[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+//-------------------------------------------------------------------------
+// critical path
+//-------------------------------------------------------------------------
+
+// HoursPerDay converts estimated hours to days for issues without dates.
+var HoursPerDay = 8.0
+
+type Path struct {
+	// Issues lists the issue ids of the path in order.
+	Issues []int
+	// Start and Finish are the earliest start and finish of the issues of
+	// the path, in days from the earliest start date of the graph.
+	Start  map[int]float64
+	Finish map[int]float64
+	// Days is the length of the path.
+	Days float64
+}
+
+// Duration returns the duration of an issue in days, from its start and due
+// dates or else from its estimated hours.
+func Duration(issue *redmine.Issue) float64 {
+	start, err1 := time.Parse(redmine.DateLayout, issue.StartDate)
+	due, err2 := time.Parse(redmine.DateLayout, issue.DueDate)
+	if err1 == nil && err2 == nil && !due.Before(start) {
+		return due.Sub(start).Hours()/24 + 1
+	}
+	return issue.EstimatedHours / HoursPerDay
+}
+
+// CriticalPath computes the longest chain of blocking and preceding
+// issues. An issue starts once all its predecessors are finished, plus the
+// delay of a precedes relation, and not before its own start date. It
+// fails when the graph has a cycle.
+func (g *Graph) CriticalPath() (*Path, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		return nil, fmt.Errorf("relations have a cycle between issues %v", cycles[0])
+	}
+	var origin time.Time
+	for _, issue := range g.Issues {
+		start, err := time.Parse(redmine.DateLayout, issue.StartDate)
+		if err == nil && (origin.IsZero() || start.Before(origin)) {
+			origin = start
+		}
+	}
+	order := g.topological()
+	start := make(map[int]float64)
+	finish := make(map[int]float64)
+	prev := make(map[int]int)
+	for _, v := range order {
+		es := 0.0
+		if issue, ok := g.Issues[v]; ok {
+			if s, err := time.Parse(redmine.DateLayout, issue.StartDate); err == nil {
+				es = s.Sub(origin).Hours() / 24
+			}
+		}
+		for _, e := range g.in[v] {
+			if !e.Gating() {
+				continue
+			}
+			t := finish[e.From]
+			if e.Type == "precedes" {
+				t += float64(e.Delay)
+			}
+			if t > es || (t == es && prev[v] == 0) {
+				es = t
+				prev[v] = e.From
+			}
+		}
+		start[v] = es
+		finish[v] = es
+		if issue, ok := g.Issues[v]; ok {
+			finish[v] += Duration(issue)
+		}
+	}
+	path := &Path{Start: start, Finish: finish}
+	last, best := 0, math.Inf(-1)
+	for _, v := range order {
+		if finish[v] > best {
+			last, best = v, finish[v]
+		}
+	}
+	if last == 0 {
+		return path, nil
+	}
+	for v := last; v != 0; v = prev[v] {
+		path.Issues = append([]int{v}, path.Issues...)
+	}
+	path.Days = finish[last] - start[path.Issues[0]]
+	return path, nil
+}
+
+// topological orders the nodes so that gating predecessors come first.
+func (g *Graph) topological() []int {
+	nodes := g.nodes()
+	indegree := make(map[int]int)
+	for _, e := range g.Edges {
+		if e.Gating() {
+			indegree[e.To]++
+		}
+	}
+	var queue, order []int
+	for _, v := range nodes {
+		if indegree[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		order = append(order, v)
+		for _, e := range g.out[v] {
+			if !e.Gating() {
+				continue
+			}
+			indegree[e.To]--
+			if indegree[e.To] == 0 {
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	return order
+}
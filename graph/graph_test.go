@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/woli/redmine"
+)
+
+func issue(id int, start, due string, hours float64, rels ...*redmine.IssueRelation) *redmine.Issue {
+	return &redmine.Issue{
+		Id:             id,
+		StartDate:      start,
+		DueDate:        due,
+		EstimatedHours: hours,
+		Relations:      rels,
+	}
+}
+
+func rel(id, from, to int, typ string, delay int) *redmine.IssueRelation {
+	return &redmine.IssueRelation{
+		Id:           id,
+		IssueId:      from,
+		IssueToId:    to,
+		RelationType: typ,
+		Delay:        redmine.Nint(delay),
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		issues []*redmine.Issue
+		want   [][]int
+	}{
+		{
+			name: "cycle",
+			issues: []*redmine.Issue{
+				issue(1, "", "", 0, rel(1, 1, 2, "blocks", 0)),
+				issue(2, "", "", 0, rel(2, 2, 3, "precedes", 0)),
+				issue(3, "", "", 0, rel(3, 3, 1, "blocks", 0)),
+				issue(4, "", "", 0, rel(4, 4, 1, "relates", 0)),
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "self loop",
+			issues: []*redmine.Issue{
+				issue(5, "", "", 0, rel(1, 5, 5, "blocks", 0)),
+			},
+			want: [][]int{{5}},
+		},
+		{
+			name: "reverse types",
+			issues: []*redmine.Issue{
+				issue(1, "", "", 0, rel(1, 1, 2, "blocked", 0)),
+				issue(2, "", "", 0, rel(2, 2, 1, "follows", 0)),
+			},
+			want: [][]int{{1, 2}},
+		},
+		{
+			name: "relates only",
+			issues: []*redmine.Issue{
+				issue(1, "", "", 0, rel(1, 1, 2, "relates", 0)),
+				issue(2, "", "", 0, rel(2, 2, 1, "relates", 0)),
+			},
+			want: nil,
+		},
+		{
+			name: "chain",
+			issues: []*redmine.Issue{
+				issue(1, "", "", 0, rel(1, 1, 2, "precedes", 0)),
+				issue(2, "", "", 0, rel(2, 2, 3, "blocks", 0)),
+				issue(3, "", "", 0),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		got := New(tt.issues, nil).Cycles()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: Cycles() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockers(t *testing.T) {
+	closed := &redmine.Name{Id: 5}
+	issues := []*redmine.Issue{
+		issue(1, "", "", 0, rel(1, 1, 2, "precedes", 0)),
+		issue(2, "", "", 0, rel(2, 2, 3, "precedes", 0)),
+		issue(3, "", "", 0, rel(3, 3, 4, "blocked", 0), rel(4, 5, 3, "relates", 0)),
+		issue(4, "", "", 0),
+		issue(5, "", "", 0),
+	}
+	issues[1].Status = closed
+	g := New(issues, map[int]bool{5: true})
+	if got, want := g.Blockers(3), []int{1, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Blockers(3) = %v, want %v", got, want)
+	}
+	if got, want := g.OpenBlockers(3), []int{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OpenBlockers(3) = %v, want %v", got, want)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		issues []*redmine.Issue
+		want   []int
+		days   float64
+		start  map[int]float64
+	}{
+		{
+			name: "chain with delay",
+			issues: []*redmine.Issue{
+				issue(1, "2024-01-01", "2024-01-02", 0, rel(1, 1, 2, "precedes", 3)),
+				issue(2, "", "", 16),
+				issue(3, "2024-01-01", "2024-01-03", 0),
+			},
+			want:  []int{1, 2},
+			days:  7,
+			start: map[int]float64{1: 0, 2: 5, 3: 0},
+		},
+		{
+			name: "blocks ignores delay",
+			issues: []*redmine.Issue{
+				issue(1, "2024-01-01", "2024-01-02", 0, rel(1, 1, 2, "blocks", 3)),
+				issue(2, "", "", 8),
+			},
+			want:  []int{1, 2},
+			days:  3,
+			start: map[int]float64{1: 0, 2: 2},
+		},
+		{
+			name: "start date dominates",
+			issues: []*redmine.Issue{
+				issue(1, "2024-01-01", "2024-01-02", 0, rel(1, 1, 2, "blocks", 0)),
+				issue(2, "2024-01-10", "2024-01-11", 0),
+			},
+			want:  []int{2},
+			days:  2,
+			start: map[int]float64{1: 0, 2: 9},
+		},
+		{
+			name: "start date equals predecessor finish",
+			issues: []*redmine.Issue{
+				issue(1, "2024-01-01", "2024-01-02", 0, rel(1, 1, 2, "blocks", 0)),
+				issue(2, "2024-01-03", "2024-01-03", 0),
+			},
+			want:  []int{1, 2},
+			days:  3,
+			start: map[int]float64{1: 0, 2: 2},
+		},
+	}
+	for _, tt := range tests {
+		path, err := New(tt.issues, nil).CriticalPath()
+		if err != nil {
+			t.Errorf("%v: CriticalPath() error %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(path.Issues, tt.want) {
+			t.Errorf("%v: Issues = %v, want %v", tt.name, path.Issues, tt.want)
+		}
+		if path.Days != tt.days {
+			t.Errorf("%v: Days = %v, want %v", tt.name, path.Days, tt.days)
+		}
+		for id, start := range tt.start {
+			if path.Start[id] != start {
+				t.Errorf("%v: Start[%v] = %v, want %v", tt.name, id, path.Start[id], start)
+			}
+		}
+	}
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+	g := New([]*redmine.Issue{
+		issue(1, "", "", 8, rel(1, 1, 2, "precedes", 0)),
+		issue(2, "", "", 8, rel(2, 2, 1, "precedes", 0)),
+	}, nil)
+	if _, err := g.CriticalPath(); err == nil {
+		t.Error("CriticalPath() on a cycle: want an error")
+	}
+}
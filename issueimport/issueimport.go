@@ -0,0 +1,462 @@
+// Package issueimport creates issues in bulk from records that refer to
+// each other by external keys, such as a backlog exported from another
+// tracker.
+package issueimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/woli/redmine"
+	"github.com/woli/redmine/lookup"
+)
+
+// Record is an issue to import. Project is a project identifier or id,
+// the other references are names, and AssignedTo is a user login. Parent
+// and the relation targets are keys of other records.
+type Record struct {
+	Key            string            `json:"key"`
+	Parent         string            `json:"parent,omitempty"`
+	Project        string            `json:"project"`
+	Tracker        string            `json:"tracker,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	Priority       string            `json:"priority,omitempty"`
+	Category       string            `json:"category,omitempty"`
+	Version        string            `json:"version,omitempty"`
+	AssignedTo     string            `json:"assigned_to,omitempty"`
+	Subject        string            `json:"subject"`
+	Description    string            `json:"description,omitempty"`
+	StartDate      string            `json:"start_date,omitempty"`
+	DueDate        string            `json:"due_date,omitempty"`
+	EstimatedHours float64           `json:"estimated_hours,omitempty"`
+	DoneRatio      int               `json:"done_ratio,omitempty"`
+	CustomFields   map[string]string `json:"custom_fields,omitempty"`
+	Relations      []*RecordRelation `json:"relations,omitempty"`
+}
+
+// RecordRelation relates a record to the record with key To.
+type RecordRelation struct {
+	Type  string `json:"type"`
+	To    string `json:"to"`
+	Delay int    `json:"delay,omitempty"`
+}
+
+var relationTypes = map[string]bool{
+	"relates": true, "duplicates": true, "duplicated": true,
+	"blocks": true, "blocked": true, "precedes": true, "follows": true,
+	"copied_to": true, "copied_from": true,
+}
+
+// reverseTypes maps the reverse relation types to the type redmine stores,
+// with the ends swapped.
+var reverseTypes = map[string]string{
+	"blocked":     "blocks",
+	"follows":     "precedes",
+	"duplicated":  "duplicates",
+	"copied_from": "copied_to",
+}
+
+type RecordError struct {
+	Key string
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Key, e.Err)
+}
+
+type Result struct {
+	// Issues maps the keys of the records to the ids of their issues,
+	// including the ones created by previous runs.
+	Issues    map[string]int
+	Created   int
+	Relations int
+	Errors    []*RecordError
+}
+
+//-------------------------------------------------------------------------
+// importer
+//-------------------------------------------------------------------------
+
+type Importer struct {
+	s          *redmine.Service
+	checkpoint string
+
+	names        *lookup.Lookup
+	customFields map[string]*redmine.CustomFieldDefinition
+}
+
+func New(s *redmine.Service) *Importer {
+	return &Importer{
+		s:     s,
+		names: lookup.New(s),
+	}
+}
+
+// Checkpoint records the created issues and relations in a file, so that
+// an interrupted import started again with the same file continues where
+// it stopped.
+func (im *Importer) Checkpoint(path string) *Importer {
+	im.checkpoint = path
+	return im
+}
+
+type checkpoint struct {
+	Issues    map[string]int  `json:"issues"`
+	Relations map[string]bool `json:"relations"`
+}
+
+func (im *Importer) load() (*checkpoint, error) {
+	cp := &checkpoint{
+		Issues:    make(map[string]int),
+		Relations: make(map[string]bool),
+	}
+	if im.checkpoint == "" {
+		return cp, nil
+	}
+	data, err := ioutil.ReadFile(im.checkpoint)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Issues == nil {
+		cp.Issues = make(map[string]int)
+	}
+	if cp.Relations == nil {
+		cp.Relations = make(map[string]bool)
+	}
+	return cp, nil
+}
+
+// save replaces the checkpoint file, so that a crash never leaves it
+// half written.
+func (im *Importer) save(cp *checkpoint) error {
+	if im.checkpoint == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := im.checkpoint + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, im.checkpoint)
+}
+
+// Import validates all the records and resolves their names first; when
+// any record is invalid nothing is created and the errors are returned in
+// the result. The issues are then created parents first, and the
+// relations once all the issues exist.
+//
+// An error returned while creating leaves the checkpoint up to date.
+func (im *Importer) Import(records []*Record) (*Result, error) {
+	cp, err := im.load()
+	if err != nil {
+		return nil, err
+	}
+	ret := &Result{Issues: cp.Issues}
+	byKey := make(map[string]*Record)
+	for _, rec := range records {
+		if rec.Key == "" {
+			ret.Errors = append(ret.Errors, &RecordError{rec.Subject, fmt.Errorf("key required")})
+			continue
+		}
+		if _, ok := byKey[rec.Key]; ok {
+			ret.Errors = append(ret.Errors, &RecordError{rec.Key, fmt.Errorf("duplicate key")})
+			continue
+		}
+		byKey[rec.Key] = rec
+	}
+	known := func(key string) bool {
+		_, inFile := byKey[key]
+		_, created := cp.Issues[key]
+		return inFile || created
+	}
+	issues := make(map[string]*redmine.Issue)
+	for _, rec := range records {
+		if byKey[rec.Key] != rec {
+			continue
+		}
+		fail := func(err error) {
+			ret.Errors = append(ret.Errors, &RecordError{rec.Key, err})
+		}
+		if rec.Parent != "" && !known(rec.Parent) {
+			fail(fmt.Errorf("unknown parent %q", rec.Parent))
+		}
+		for _, rel := range rec.Relations {
+			if !relationTypes[rel.Type] {
+				fail(fmt.Errorf("unknown relation type %q", rel.Type))
+			}
+			if !known(rel.To) {
+				fail(fmt.Errorf("unknown related issue %q", rel.To))
+			}
+		}
+		if _, ok := cp.Issues[rec.Key]; ok {
+			continue
+		}
+		issue, errs := im.resolve(rec)
+		for _, err := range errs {
+			fail(err)
+		}
+		issues[rec.Key] = issue
+	}
+	order, cycle := sortParents(records, byKey)
+	if cycle != nil {
+		ret.Errors = append(ret.Errors, cycle)
+	}
+	if len(ret.Errors) > 0 {
+		return ret, nil
+	}
+
+	for _, rec := range order {
+		if _, ok := cp.Issues[rec.Key]; ok {
+			continue
+		}
+		issue := issues[rec.Key]
+		if rec.Parent != "" {
+			issue.Parent = &redmine.Id{Id: cp.Issues[rec.Parent]}
+		}
+		created, err := im.s.Issues.Insert(issue).Do()
+		if err != nil {
+			return ret, &RecordError{rec.Key, err}
+		}
+		cp.Issues[rec.Key] = created.Id
+		ret.Created++
+		err = im.save(cp)
+		if err != nil {
+			return ret, err
+		}
+	}
+	for _, rel := range relations(order) {
+		if cp.Relations[rel.id()] {
+			continue
+		}
+		relation := &redmine.Relation{
+			IssueId:      cp.Issues[rel.from],
+			IssueToId:    cp.Issues[rel.to],
+			RelationType: rel.typ,
+			Delay:        rel.delay,
+		}
+		_, err := im.s.Relations.Insert(relation).Do()
+		if err != nil {
+			// a relation created outside the checkpoint, e.g. by hand, is
+			// rejected by redmine and counts as done
+			exists, listErr := im.relationExists(relation)
+			if listErr != nil || !exists {
+				return ret, &RecordError{rel.from, fmt.Errorf("relation %v %v: %v", rel.typ, rel.to, err)}
+			}
+		} else {
+			ret.Relations++
+		}
+		cp.Relations[rel.id()] = true
+		err = im.save(cp)
+		if err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+type relationKey struct {
+	from  string
+	to    string
+	typ   string
+	delay int
+}
+
+func (r *relationKey) id() string {
+	return fmt.Sprintf("%v %v %v", r.from, r.typ, r.to)
+}
+
+// relations lists the relations of the records the way redmine stores
+// them: reverse types are turned into their forward type with the ends
+// swapped, and the ends of relates are sorted. A relation given on both
+// of its ends, such as "A blocks B" and "B blocked A", is listed once.
+func relations(records []*Record) []*relationKey {
+	var ret []*relationKey
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		for _, rel := range rec.Relations {
+			key := &relationKey{rec.Key, rel.To, rel.Type, rel.Delay}
+			if t, ok := reverseTypes[key.typ]; ok {
+				key.from, key.to, key.typ = key.to, key.from, t
+			}
+			if key.typ == "relates" && key.to < key.from {
+				key.from, key.to = key.to, key.from
+			}
+			if seen[key.id()] {
+				continue
+			}
+			seen[key.id()] = true
+			ret = append(ret, key)
+		}
+	}
+	return ret
+}
+
+// relationExists tells whether the issues of relation are already related
+// with its type, in either direction.
+func (im *Importer) relationExists(relation *redmine.Relation) (bool, error) {
+	existing, err := im.s.Relations.List(relation.IssueId).Do()
+	if err != nil {
+		return false, err
+	}
+	for _, r := range existing {
+		if r.RelationType != relation.RelationType {
+			continue
+		}
+		if r.IssueId == relation.IssueId && r.IssueToId == relation.IssueToId ||
+			r.IssueId == relation.IssueToId && r.IssueToId == relation.IssueId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sortParents orders the records so that parents come before their
+// children, keeping the order of the records otherwise.
+func sortParents(records []*Record, byKey map[string]*Record) ([]*Record, *RecordError) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []*Record
+	var visit func(rec *Record) *RecordError
+	visit = func(rec *Record) *RecordError {
+		switch state[rec.Key] {
+		case visiting:
+			return &RecordError{rec.Key, fmt.Errorf("parent cycle")}
+		case done:
+			return nil
+		}
+		state[rec.Key] = visiting
+		if parent, ok := byKey[rec.Parent]; ok {
+			err := visit(parent)
+			if err != nil {
+				return err
+			}
+		}
+		state[rec.Key] = done
+		order = append(order, rec)
+		return nil
+	}
+	for _, rec := range records {
+		if byKey[rec.Key] != rec {
+			continue
+		}
+		err := visit(rec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+//-------------------------------------------------------------------------
+// name resolution
+//-------------------------------------------------------------------------
+
+// resolve builds the issue of a record, without its parent.
+func (im *Importer) resolve(rec *Record) (*redmine.Issue, []error) {
+	var errs []error
+	issue := &redmine.Issue{
+		Subject:        rec.Subject,
+		Description:    rec.Description,
+		StartDate:      rec.StartDate,
+		DueDate:        rec.DueDate,
+		EstimatedHours: rec.EstimatedHours,
+		DoneRatio:      rec.DoneRatio,
+	}
+	if rec.Subject == "" {
+		errs = append(errs, fmt.Errorf("subject required"))
+	}
+	ref := func(field, value string, lookup func(string) (int, error)) *redmine.Name {
+		if value == "" {
+			return nil
+		}
+		id, err := lookup(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", field, err))
+			return nil
+		}
+		return &redmine.Name{Id: id, Name: value}
+	}
+	issue.Project = ref("project", rec.Project, im.names.Project)
+	if issue.Project == nil {
+		if rec.Project == "" {
+			errs = append(errs, fmt.Errorf("project required"))
+		}
+		return issue, errs
+	}
+	projectId := issue.Project.Id
+	issue.Tracker = ref("tracker", rec.Tracker, im.names.Tracker)
+	issue.Status = ref("status", rec.Status, im.names.Status)
+	issue.Priority = ref("priority", rec.Priority, im.names.Priority)
+	issue.Category = ref("category", rec.Category, func(name string) (int, error) {
+		return im.names.Category(projectId, name)
+	})
+	issue.FixedVersion = ref("version", rec.Version, func(name string) (int, error) {
+		return im.names.Version(projectId, name)
+	})
+	issue.AssignedTo = ref("assignee", rec.AssignedTo, im.names.User)
+	names := make([]string, 0, len(rec.CustomFields))
+	for name := range rec.CustomFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cf, err := im.customField(name, rec.CustomFields[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		issue.CustomFields = append(issue.CustomFields, cf)
+	}
+	return issue, errs
+}
+
+// customField builds a validated issue custom field. The values of
+// multi-value fields are separated by commas.
+func (im *Importer) customField(name, value string) (*redmine.CustomField, error) {
+	if im.customFields == nil {
+		definitions, err := im.s.CustomFields.List().Do()
+		if err != nil {
+			return nil, err
+		}
+		im.customFields = make(map[string]*redmine.CustomFieldDefinition)
+		for _, d := range definitions {
+			if d.CustomizedType == "issue" {
+				im.customFields[strings.ToLower(d.Name)] = d
+			}
+		}
+	}
+	d, ok := im.customFields[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown custom field %q", name)
+	}
+	var values []string
+	if d.Multiple {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	} else if value != "" {
+		values = []string{value}
+	}
+	return d.Value(values...)
+}
@@ -0,0 +1,173 @@
+package issueimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func records(pairs ...string) ([]*Record, map[string]*Record) {
+	var recs []*Record
+	byKey := make(map[string]*Record)
+	for i := 0; i < len(pairs); i += 2 {
+		rec := &Record{Key: pairs[i], Parent: pairs[i+1]}
+		recs = append(recs, rec)
+		byKey[rec.Key] = rec
+	}
+	return recs, byKey
+}
+
+func TestSortParents(t *testing.T) {
+	tests := []struct {
+		name  string
+		pairs []string
+		want  []string
+		cycle bool
+	}{
+		{
+			name:  "flat",
+			pairs: []string{"A", "", "B", "", "C", ""},
+			want:  []string{"A", "B", "C"},
+		},
+		{
+			name:  "children before parents",
+			pairs: []string{"C", "B", "B", "A", "D", "", "A", ""},
+			want:  []string{"A", "B", "C", "D"},
+		},
+		{
+			// a parent outside the file was created by a previous run
+			name:  "parent outside",
+			pairs: []string{"B", "X", "A", ""},
+			want:  []string{"B", "A"},
+		},
+		{
+			name:  "cycle",
+			pairs: []string{"A", "B", "B", "A"},
+			cycle: true,
+		},
+		{
+			name:  "self parent",
+			pairs: []string{"A", "A"},
+			cycle: true,
+		},
+	}
+	for _, tt := range tests {
+		recs, byKey := records(tt.pairs...)
+		order, cycle := sortParents(recs, byKey)
+		if tt.cycle {
+			if cycle == nil {
+				t.Errorf("%v: want a cycle error", tt.name)
+			}
+			continue
+		}
+		if cycle != nil {
+			t.Errorf("%v: unexpected error %v", tt.name, cycle)
+			continue
+		}
+		var keys []string
+		for _, rec := range order {
+			keys = append(keys, rec.Key)
+		}
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("%v: order = %v, want %v", tt.name, keys, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	in := "key,parent,project,subject,estimated_hours,done_ratio,relations,cf:Client\n" +
+		"B-1,,web,Login,2.5,10,\"blocks:B-2; precedes:B-3:2\",ACME\n" +
+		"B-2,B-1,web,Form,,,,\n"
+	recs, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Record{
+		{
+			Key:            "B-1",
+			Project:        "web",
+			Subject:        "Login",
+			EstimatedHours: 2.5,
+			DoneRatio:      10,
+			Relations: []*RecordRelation{
+				{Type: "blocks", To: "B-2"},
+				{Type: "precedes", To: "B-3", Delay: 2},
+			},
+			CustomFields: map[string]string{"Client": "ACME"},
+		},
+		{
+			Key:     "B-2",
+			Parent:  "B-1",
+			Project: "web",
+			Subject: "Form",
+		},
+	}
+	if !reflect.DeepEqual(recs, want) {
+		t.Errorf("ReadCSV() = %+v, want %+v", recs, want)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"unknown column", "key,colour\nA,red\n", "line 2, colour: unknown column"},
+		{"bad hours", "key,estimated_hours\nA,two\n", "line 2, estimated_hours"},
+		{"bad relation", "key,relations\nA,blocks\n", "invalid relation"},
+		{"bad delay", "key,relations\nA,precedes:B:x\n", "invalid relation delay"},
+	}
+	for _, tt := range tests {
+		_, err := ReadCSV(strings.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRelations(t *testing.T) {
+	rel := func(key, typ, to string, delay int) *Record {
+		return &Record{Key: key, Relations: []*RecordRelation{{Type: typ, To: to, Delay: delay}}}
+	}
+	tests := []struct {
+		name    string
+		records []*Record
+		want    []relationKey
+	}{
+		{
+			name:    "forward",
+			records: []*Record{rel("A", "blocks", "B", 0)},
+			want:    []relationKey{{"A", "B", "blocks", 0}},
+		},
+		{
+			name:    "reverse",
+			records: []*Record{rel("B", "follows", "A", 2)},
+			want:    []relationKey{{"A", "B", "precedes", 2}},
+		},
+		{
+			name:    "both ends",
+			records: []*Record{rel("A", "blocks", "B", 0), rel("B", "blocked", "A", 0)},
+			want:    []relationKey{{"A", "B", "blocks", 0}},
+		},
+		{
+			name:    "relates both ends",
+			records: []*Record{rel("B", "relates", "A", 0), rel("A", "relates", "B", 0)},
+			want:    []relationKey{{"A", "B", "relates", 0}},
+		},
+		{
+			name:    "opposite directions",
+			records: []*Record{rel("A", "precedes", "B", 0), rel("A", "follows", "B", 0)},
+			want:    []relationKey{{"A", "B", "precedes", 0}, {"B", "A", "precedes", 0}},
+		},
+	}
+	for _, tt := range tests {
+		var got []relationKey
+		for _, key := range relations(tt.records) {
+			got = append(got, *key)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: relations() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
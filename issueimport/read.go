@@ -0,0 +1,143 @@
+package issueimport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//-------------------------------------------------------------------------
+// readers
+//-------------------------------------------------------------------------
+
+// ReadJSON reads an array of records.
+func ReadJSON(r io.Reader) ([]*Record, error) {
+	var records []*Record
+	err := json.NewDecoder(r).Decode(&records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ReadCSV reads records from a CSV file whose header names the record
+// fields as in JSON, e.g. key, parent, project and subject. Columns named
+// "cf:<name>" hold custom fields. The relations column lists relations
+// separated by semicolons, each written "type:key" or "type:key:delay",
+// e.g. "blocks:B-12; precedes:B-14:2".
+func ReadCSV(r io.Reader) ([]*Record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var records []*Record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := new(Record)
+		for i, value := range row {
+			if i >= len(header) {
+				break
+			}
+			err := rec.set(header[i], strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("line %v, %v: %v", line, header[i], err)
+			}
+		}
+		records = append(records, rec)
+	}
+}
+
+func (rec *Record) set(column, value string) error {
+	if strings.HasPrefix(column, "cf:") {
+		if value != "" {
+			if rec.CustomFields == nil {
+				rec.CustomFields = make(map[string]string)
+			}
+			rec.CustomFields[strings.TrimPrefix(column, "cf:")] = value
+		}
+		return nil
+	}
+	var err error
+	switch strings.ToLower(column) {
+	case "key":
+		rec.Key = value
+	case "parent":
+		rec.Parent = value
+	case "project":
+		rec.Project = value
+	case "tracker":
+		rec.Tracker = value
+	case "status":
+		rec.Status = value
+	case "priority":
+		rec.Priority = value
+	case "category":
+		rec.Category = value
+	case "version":
+		rec.Version = value
+	case "assigned_to":
+		rec.AssignedTo = value
+	case "subject":
+		rec.Subject = value
+	case "description":
+		rec.Description = value
+	case "start_date":
+		rec.StartDate = value
+	case "due_date":
+		rec.DueDate = value
+	case "estimated_hours":
+		if value != "" {
+			rec.EstimatedHours, err = strconv.ParseFloat(value, 64)
+		}
+	case "done_ratio":
+		if value != "" {
+			rec.DoneRatio, err = strconv.Atoi(value)
+		}
+	case "relations":
+		rec.Relations, err = parseRelations(value)
+	default:
+		err = fmt.Errorf("unknown column")
+	}
+	return err
+}
+
+func parseRelations(s string) ([]*RecordRelation, error) {
+	var relations []*RecordRelation
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid relation %q", part)
+		}
+		rel := &RecordRelation{
+			Type: strings.TrimSpace(fields[0]),
+			To:   strings.TrimSpace(fields[1]),
+		}
+		if len(fields) == 3 {
+			delay, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid relation delay %q", part)
+			}
+			rel.Delay = delay
+		}
+		relations = append(relations, rel)
+	}
+	return relations, nil
+}
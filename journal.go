@@ -0,0 +1,254 @@
+package redmine
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//-------------------------------------------------------------------------
+// journal renderer
+//-------------------------------------------------------------------------
+
+var journalAttributeLabels = map[string]string{
+	"project_id":       "Project",
+	"tracker_id":       "Tracker",
+	"subject":          "Subject",
+	"description":      "Description",
+	"status_id":        "Status",
+	"priority_id":      "Priority",
+	"assigned_to_id":   "Assignee",
+	"category_id":      "Category",
+	"fixed_version_id": "Target version",
+	"parent_id":        "Parent task",
+	"start_date":       "Start date",
+	"due_date":         "Due date",
+	"done_ratio":       "% Done",
+	"estimated_hours":  "Estimated time",
+	"is_private":       "Private",
+}
+
+// JournalRenderer turns journal details into sentences such as "Status
+// changed from New to In Progress". The names behind the ids are fetched
+// on first use and cached. Custom fields are named from their definitions,
+// which only administrators can list; other users can pass them with
+// CustomFields, or the fields are shown by id.
+type JournalRenderer struct {
+	s            *Service
+	customFields map[int]*CustomFieldDefinition
+	statuses     map[int]string
+	trackers     map[int]string
+	priorities   map[int]string
+	users        map[int]string
+	versions     map[int]string
+	categories   map[int]string
+	projects     map[int]string
+}
+
+func (r *JournalsService) Renderer() *JournalRenderer {
+	return &JournalRenderer{
+		s:          r.s,
+		users:      make(map[int]string),
+		versions:   make(map[int]string),
+		categories: make(map[int]string),
+		projects:   make(map[int]string),
+	}
+}
+
+// CustomFields sets the custom field definitions used to name the fields
+// and their values, instead of listing them.
+func (r *JournalRenderer) CustomFields(definitions []*CustomFieldDefinition) *JournalRenderer {
+	r.customFields = make(map[int]*CustomFieldDefinition)
+	for _, d := range definitions {
+		r.customFields[d.Id] = d
+	}
+	return r
+}
+
+// RenderJournal renders every detail of a journal.
+func (r *JournalRenderer) RenderJournal(journal *IssueJournal) ([]string, error) {
+	lines := make([]string, 0, len(journal.Details))
+	for _, detail := range journal.Details {
+		line, err := r.Render(detail)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (r *JournalRenderer) Render(detail *IssueJournalDetail) (string, error) {
+	switch detail.Property {
+	case "attachment":
+		if detail.NewValue != "" {
+			return fmt.Sprintf("File %v added", detail.NewValue), nil
+		}
+		return fmt.Sprintf("File deleted (%v)", detail.OldValue), nil
+	case "relation":
+		if detail.NewValue != "" {
+			return fmt.Sprintf("Relation %v #%v added", detail.Name, detail.NewValue), nil
+		}
+		return fmt.Sprintf("Relation %v deleted (#%v)", detail.Name, detail.OldValue), nil
+	case "cf":
+		return r.renderCustomField(detail), nil
+	case "attr":
+		label, ok := journalAttributeLabels[detail.Name]
+		if !ok {
+			label = detail.Name
+		}
+		if detail.Name == "description" {
+			return "Description updated", nil
+		}
+		oldValue, err := r.value(detail.Name, detail.OldValue)
+		if err != nil {
+			return "", err
+		}
+		newValue, err := r.value(detail.Name, detail.NewValue)
+		if err != nil {
+			return "", err
+		}
+		return renderChange(label, oldValue, newValue), nil
+	}
+	return renderChange(detail.Name, detail.OldValue, detail.NewValue), nil
+}
+
+func renderChange(label, oldValue, newValue string) string {
+	switch {
+	case oldValue == "":
+		return fmt.Sprintf("%v set to %v", label, newValue)
+	case newValue == "":
+		return fmt.Sprintf("%v deleted (%v)", label, oldValue)
+	}
+	return fmt.Sprintf("%v changed from %v to %v", label, oldValue, newValue)
+}
+
+func (r *JournalRenderer) value(name, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return value, nil
+	}
+	switch name {
+	case "status_id":
+		if r.statuses == nil {
+			statuses, err := r.s.IssueStatuses.List().Do()
+			if err != nil {
+				return "", err
+			}
+			r.statuses = make(map[int]string)
+			for _, status := range statuses {
+				r.statuses[status.Id] = status.Name
+			}
+		}
+		return lookupName(r.statuses, id), nil
+	case "tracker_id":
+		if r.trackers == nil {
+			trackers, err := r.s.Trackers.List().Do()
+			if err != nil {
+				return "", err
+			}
+			r.trackers = make(map[int]string)
+			for _, tracker := range trackers {
+				r.trackers[tracker.Id] = tracker.Name
+			}
+		}
+		return lookupName(r.trackers, id), nil
+	case "priority_id":
+		if r.priorities == nil {
+			priorities, err := r.s.Enumerations.IssuePriorities.List().Do()
+			if err != nil {
+				return "", err
+			}
+			r.priorities = make(map[int]string)
+			for _, priority := range priorities {
+				r.priorities[priority.Id] = priority.Name
+			}
+		}
+		return lookupName(r.priorities, id), nil
+	case "assigned_to_id":
+		return r.user(id), nil
+	case "fixed_version_id":
+		return r.version(id), nil
+	case "category_id":
+		if _, ok := r.categories[id]; !ok {
+			if category, err := r.s.IssueCategories.Get(id).Do(); err == nil {
+				r.categories[id] = category.Name
+			}
+		}
+		return lookupName(r.categories, id), nil
+	case "project_id":
+		if _, ok := r.projects[id]; !ok {
+			if project, err := r.s.Projects.Get(ProjectId(id)).Do(); err == nil {
+				r.projects[id] = project.Name
+			}
+		}
+		return lookupName(r.projects, id), nil
+	case "parent_id":
+		return "#" + value, nil
+	}
+	return value, nil
+}
+
+// user names a user; deleted or invisible users are shown by id.
+func (r *JournalRenderer) user(id int) string {
+	if _, ok := r.users[id]; !ok {
+		if user, err := r.s.Users.Get(id).Do(); err == nil {
+			r.users[id] = user.Firstname + " " + user.Lastname
+		}
+	}
+	return lookupName(r.users, id)
+}
+
+func (r *JournalRenderer) version(id int) string {
+	if _, ok := r.versions[id]; !ok {
+		if version, err := r.s.Versions.Get(id).Do(); err == nil {
+			r.versions[id] = version.Name
+		}
+	}
+	return lookupName(r.versions, id)
+}
+
+// renderCustomField names the custom field of a detail and shows the users,
+// versions and labelled values it refers to by name.
+func (r *JournalRenderer) renderCustomField(detail *IssueJournalDetail) string {
+	if r.customFields == nil {
+		definitions, _ := r.s.CustomFields.List().Do()
+		r.CustomFields(definitions)
+	}
+	id, _ := strconv.Atoi(detail.Name)
+	d, ok := r.customFields[id]
+	if !ok {
+		return renderChange("Custom field "+detail.Name, detail.OldValue, detail.NewValue)
+	}
+	value := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		for _, p := range d.PossibleValues {
+			if p.Value == value && p.Label != "" {
+				return p.Label
+			}
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return value
+		}
+		switch d.FieldFormat {
+		case "user":
+			return r.user(id)
+		case "version":
+			return r.version(id)
+		}
+		return value
+	}
+	return renderChange(d.Name, value(detail.OldValue), value(detail.NewValue))
+}
+
+func lookupName(names map[int]string, id int) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
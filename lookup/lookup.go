@@ -0,0 +1,167 @@
+// Package lookup resolves the names used in imported files, such as
+// project identifiers, user logins and tracker names, to redmine ids. The
+// lists it fetches are cached, so a lookup is made once per name.
+package lookup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/woli/redmine"
+)
+
+type Lookup struct {
+	s *redmine.Service
+
+	projects   map[string]int
+	users      map[string]int
+	trackers   map[string]int
+	statuses   map[string]int
+	priorities map[string]int
+	activities map[string]int
+	categories map[int]map[string]int
+	versions   map[int]map[string]int
+}
+
+func New(s *redmine.Service) *Lookup {
+	return &Lookup{
+		s:          s,
+		projects:   make(map[string]int),
+		users:      make(map[string]int),
+		categories: make(map[int]map[string]int),
+		versions:   make(map[int]map[string]int),
+	}
+}
+
+// find finds a name in a name to id map, ignoring case.
+func find(names map[string]int, kind, name string) (int, error) {
+	if id, ok := names[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown %v %q", kind, name)
+}
+
+// Project finds a project by identifier or id.
+func (l *Lookup) Project(identifier string) (int, error) {
+	if id, ok := l.projects[identifier]; ok {
+		return id, nil
+	}
+	var ref redmine.ProjectRef = redmine.ProjectIdentifier(identifier)
+	if id, err := strconv.Atoi(identifier); err == nil {
+		ref = redmine.ProjectId(id)
+	}
+	project, err := l.s.Projects.Get(ref).Do()
+	if err != nil {
+		return 0, fmt.Errorf("unknown project %q: %v", identifier, err)
+	}
+	l.projects[identifier] = project.Id
+	return project.Id, nil
+}
+
+// User finds a user by login, ignoring case.
+func (l *Lookup) User(login string) (int, error) {
+	if id, ok := l.users[login]; ok {
+		return id, nil
+	}
+	feed, err := l.s.Users.List().Name(login).Do()
+	if err != nil {
+		return 0, err
+	}
+	for _, user := range feed.Users {
+		if strings.EqualFold(user.Login, login) {
+			l.users[login] = user.Id
+			return user.Id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown user %q", login)
+}
+
+func (l *Lookup) Tracker(name string) (int, error) {
+	if l.trackers == nil {
+		trackers, err := l.s.Trackers.List().Do()
+		if err != nil {
+			return 0, err
+		}
+		l.trackers = make(map[string]int)
+		for _, t := range trackers {
+			l.trackers[strings.ToLower(t.Name)] = t.Id
+		}
+	}
+	return find(l.trackers, "tracker", name)
+}
+
+func (l *Lookup) Status(name string) (int, error) {
+	if l.statuses == nil {
+		statuses, err := l.s.IssueStatuses.List().Do()
+		if err != nil {
+			return 0, err
+		}
+		l.statuses = make(map[string]int)
+		for _, st := range statuses {
+			l.statuses[strings.ToLower(st.Name)] = st.Id
+		}
+	}
+	return find(l.statuses, "status", name)
+}
+
+func (l *Lookup) Priority(name string) (int, error) {
+	if l.priorities == nil {
+		priorities, err := l.s.Enumerations.IssuePriorities.List().Do()
+		if err != nil {
+			return 0, err
+		}
+		l.priorities = make(map[string]int)
+		for _, p := range priorities {
+			l.priorities[strings.ToLower(p.Name)] = p.Id
+		}
+	}
+	return find(l.priorities, "priority", name)
+}
+
+func (l *Lookup) Activity(name string) (int, error) {
+	if l.activities == nil {
+		activities, err := l.s.Enumerations.TimeEntryActivities.List().Do()
+		if err != nil {
+			return 0, err
+		}
+		l.activities = make(map[string]int)
+		for _, a := range activities {
+			l.activities[strings.ToLower(a.Name)] = a.Id
+		}
+	}
+	return find(l.activities, "activity", name)
+}
+
+func (l *Lookup) Category(projectId int, name string) (int, error) {
+	names, ok := l.categories[projectId]
+	if !ok {
+		categories, err := l.s.IssueCategories.List(redmine.ProjectId(projectId)).Do()
+		if err != nil {
+			return 0, err
+		}
+		names = make(map[string]int)
+		for _, c := range categories {
+			names[strings.ToLower(c.Name)] = c.Id
+		}
+		l.categories[projectId] = names
+	}
+	return find(names, "category", name)
+}
+
+// Version finds the versions of the project, including the shared ones.
+func (l *Lookup) Version(projectId int, name string) (int, error) {
+	names, ok := l.versions[projectId]
+	if !ok {
+		versions, err := l.s.Versions.List(redmine.ProjectId(projectId)).Do()
+		if err != nil {
+			return 0, err
+		}
+		names = make(map[string]int)
+		for _, v := range versions {
+			names[strings.ToLower(v.Name)] = v.Id
+		}
+		l.versions[projectId] = names
+	}
+	return find(names, "version", name)
+}
@@ -7,15 +7,25 @@ package redmine
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Service struct {
@@ -41,6 +51,11 @@ type Service struct {
 	IssueCategories *IssueCategoriesService
 	Roles           *RolesService
 	Groups          *GroupsService
+	Files           *FilesService
+	Search          *SearchService
+	MyAccount       *MyAccountService
+	Journals        *JournalsService
+	CustomFields    *CustomFieldsService
 }
 
 type service struct {
@@ -72,6 +87,11 @@ type TimeEntryActivitiesService service
 type IssueCategoriesService service
 type RolesService service
 type GroupsService service
+type FilesService service
+type SearchService service
+type MyAccountService service
+type JournalsService service
+type CustomFieldsService service
 
 func New(baseUrl string, auth Authenticator, client *http.Client) (*Service, error) {
 	if auth == nil {
@@ -107,6 +127,11 @@ func New(baseUrl string, auth Authenticator, client *http.Client) (*Service, err
 	s.IssueCategories = &IssueCategoriesService{s}
 	s.Roles = &RolesService{s}
 	s.Groups = &GroupsService{s}
+	s.Files = &FilesService{s}
+	s.Search = &SearchService{s}
+	s.MyAccount = &MyAccountService{s}
+	s.Journals = &JournalsService{s}
+	s.CustomFields = &CustomFieldsService{s}
 	return s, nil
 }
 
@@ -153,13 +178,24 @@ func resolveRelative(basestr, relstr string) string {
 	return us
 }
 
-func (s *Service) doRequest(method, urlStr string, body io.Reader) ([]byte, error) {
-	req, _ := http.NewRequest(method, urlStr, body)
+func (s *Service) newRequest(method, urlStr string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
 	s.auth.SetAuth(req)
-	req.Header.Set("Content-Type", "application/json")
 	if s.switchUser != "" {
 		req.Header.Set("X-Redmine-Switch-User", s.switchUser)
 	}
+	return req, nil
+}
+
+func (s *Service) doRequest(method, urlStr string, body io.Reader) ([]byte, error) {
+	req, err := s.newRequest(method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
 	res, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -204,19 +240,141 @@ type Name struct {
 	Name string `json:"name"`
 }
 
+// DateLayout is the layout of the dates exchanged with redmine, such as
+// spent_on, start_date and due_date.
+const DateLayout = "2006-01-02"
+
+// ProjectRef identifies a project either by its id, ProjectId(1), or by
+// its identifier, ProjectIdentifier("redmine").
+type ProjectRef interface {
+	// String returns the id or identifier, as used in URLs and filters.
+	String() string
+	isProjectRef()
+}
+
+type ProjectId int
+
+func (id ProjectId) String() string {
+	return strconv.Itoa(int(id))
+}
+
+func (ProjectId) isProjectRef() {}
+
+type ProjectIdentifier string
+
+func (id ProjectIdentifier) String() string {
+	return string(id)
+}
+
+func (ProjectIdentifier) isProjectRef() {}
+
+// projectRef returns the path segment of a project, failing on a nil,
+// zero or empty reference.
+func projectRef(ref ProjectRef) (string, error) {
+	switch v := ref.(type) {
+	case ProjectId:
+		if v > 0 {
+			return v.String(), nil
+		}
+	case ProjectIdentifier:
+		if v != "" {
+			return v.String(), nil
+		}
+	}
+	return "", fmt.Errorf("invalid project %v", ref)
+}
+
+// expandProject replaces {projectId} in urlStr with the project.
+func expandProject(urlStr string, ref ProjectRef) (string, error) {
+	id, err := projectRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(urlStr, "{projectId}", id, 1), nil
+}
+
 //-------------------------------------------------------------------------
 // custom fields
 //-------------------------------------------------------------------------
 
+// CustomField is the value of a custom field. Multi-value fields
+// (Multiple) hold their values in Values, the others in Value.
 type CustomField struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Id       int      `json:"id"`
+	Name     string   `json:"name"`
+	Multiple bool     `json:"multiple"`
+	Value    string   `json:"value"`
+	Values   []string `json:"-"`
+}
+
+func (r *CustomField) UnmarshalJSON(b []byte) error {
+	v := struct {
+		Id       int             `json:"id"`
+		Name     string          `json:"name"`
+		Multiple bool            `json:"multiple"`
+		Value    json.RawMessage `json:"value"`
+	}{}
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	r.Id = v.Id
+	r.Name = v.Name
+	r.Multiple = v.Multiple
+	r.Value = ""
+	r.Values = nil
+	if len(v.Value) == 0 || string(v.Value) == "null" {
+		return nil
+	}
+	if v.Value[0] == '[' {
+		r.Multiple = true
+		return json.Unmarshal(v.Value, &r.Values)
+	}
+	return json.Unmarshal(v.Value, &r.Value)
+}
+
+// MarshalJSON writes the values of multi-value fields as a value array, the
+// way redmine does, so that they survive a round trip.
+func (r CustomField) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Id       int         `json:"id"`
+		Name     string      `json:"name"`
+		Multiple bool        `json:"multiple"`
+		Value    interface{} `json:"value"`
+	}{
+		Id:       r.Id,
+		Name:     r.Name,
+		Multiple: r.Multiple,
+		Value:    r.Value,
+	}
+	if r.Multiple {
+		values := r.Values
+		if values == nil {
+			values = []string{}
+		}
+		v.Value = values
+	}
+	return json.Marshal(&v)
 }
 
 type customField struct {
-	Id    int    `json:"id,omitempty"`
-	Value string `json:"value,omitempty"`
+	Id    int         `json:"id,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+func (r *CustomField) toSend() *customField {
+	newCustomField := new(customField)
+	newCustomField.Id = r.Id
+	if r.Multiple {
+		values := r.Values
+		if values == nil {
+			values = []string{}
+		}
+		newCustomField.Value = values
+	} else if r.Value != "" {
+		newCustomField.Value = r.Value
+	}
+	return newCustomField
 }
 
 //-------------------------------------------------------------------------
@@ -278,6 +436,110 @@ func (c *UploadsUploadCall) Do() (string, error) {
 	return ret.Upload.Token, nil
 }
 
+//-------------------------------------------------------------------------
+// upload file from reader
+//-------------------------------------------------------------------------
+
+type UploadsUploadReaderCall struct {
+	s        *Service
+	r        io.Reader
+	size     int64
+	filename string
+	progress func(written, total int64)
+}
+
+func (r *UploadsService) UploadReader(reader io.Reader, size int64) *UploadsUploadReaderCall {
+	return &UploadsUploadReaderCall{
+		s:    r.s,
+		r:    reader,
+		size: size,
+	}
+}
+
+func (c *UploadsUploadReaderCall) Filename(filename string) *UploadsUploadReaderCall {
+	c.filename = filename
+	return c
+}
+
+// Progress registers a callback that is invoked as the body is sent. total is
+// the size given to UploadReader.
+func (c *UploadsUploadReaderCall) Progress(progress func(written, total int64)) *UploadsUploadReaderCall {
+	c.progress = progress
+	return c
+}
+
+func (c *UploadsUploadReaderCall) Do() (*Upload, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(c.r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if byExt := mime.TypeByExtension(path.Ext(c.filename)); byExt != "" && strings.HasPrefix(contentType, "application/octet-stream") {
+		contentType = byExt
+	}
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), c.r)
+	if c.progress != nil {
+		body = &progressReader{r: body, total: c.size, progress: c.progress}
+	}
+	params := make(url.Values)
+	if c.filename != "" {
+		params.Set("filename", c.filename)
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "uploads.json")
+	urlStr += "?" + params.Encode()
+	req, err := c.s.newRequest("POST", urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = c.size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = checkResponse(res.StatusCode, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		Upload struct {
+			Token string `json:"token"`
+		} `json:"upload"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{
+		Token:       ret.Upload.Token,
+		Filename:    c.filename,
+		ContentType: contentType,
+	}, nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.written += int64(n)
+		r.progress(r.written, r.total)
+	}
+	return n, err
+}
+
 //-------------------------------------------------------------------------
 // issues
 //-------------------------------------------------------------------------
@@ -315,7 +577,7 @@ type Issue struct {
 	Attachments    []*Attachment     `json:"attachments"`
 	Journals       []*IssueJournal   `json:"journals"`
 	Changesets     []*IssueChangeset `json:"changesets"`
-	// TODO: watchers (since 2.3)
+	Watchers       []*Name           `json:"watchers"`
 }
 
 type IssueRelation struct {
@@ -335,15 +597,18 @@ type IssueChild struct {
 type IssueJournalDetail struct {
 	Name     string `json:"name"`
 	Property string `json:"property"`
+	OldValue string `json:"old_value"`
 	NewValue string `json:"new_value"`
 }
 
 type IssueJournal struct {
-	Id        int                   `json:"id"`
-	User      *Name                 `json:"user"`
-	Details   []*IssueJournalDetail `json:"details"`
-	Notes     string                `json:"notes"`
-	CreatedOn string                `json:"created_on"`
+	Id           int                   `json:"id"`
+	User         *Name                 `json:"user"`
+	Details      []*IssueJournalDetail `json:"details"`
+	Notes        string                `json:"notes"`
+	PrivateNotes bool                  `json:"private_notes"`
+	CreatedOn    string                `json:"created_on"`
+	UpdatedOn    string                `json:"updated_on"`
 }
 
 type IssueChangeset struct {
@@ -380,6 +645,7 @@ type issue struct {
 	FixedVersionId int            `json:"fixed_version_id,omitempty"`
 	CategoryId     int            `json:"category_id,omitempty"`
 	CustomFields   []*customField `json:"custom_fields,omitempty"`
+	WatcherUserIds []int          `json:"watcher_user_ids,omitempty"`
 	Uploads        []*Upload      `json:"uploads,omitempty"`
 }
 
@@ -401,7 +667,7 @@ func (r *Issue) toSend(uploads []*Upload) *issue {
 	if r.Project != nil {
 		newIssue.ProjectId = r.Project.Id
 	}
-	if r.AssignedTo != nil {
+	if r.Author != nil {
 		newIssue.AuthorId = r.Author.Id
 	}
 	newIssue.StartDate = r.StartDate
@@ -422,10 +688,13 @@ func (r *Issue) toSend(uploads []*Upload) *issue {
 	if r.CustomFields != nil {
 		newIssue.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newIssue.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newIssue.CustomFields[i] = cf.toSend()
+		}
+	}
+	if r.Watchers != nil {
+		newIssue.WatcherUserIds = make([]int, len(r.Watchers))
+		for i, watcher := range r.Watchers {
+			newIssue.WatcherUserIds[i] = watcher.Id
 		}
 	}
 	newIssue.Uploads = uploads
@@ -466,7 +735,17 @@ func (c *IssuesListCall) Sort(sort string) *IssuesListCall {
 }
 
 func (c *IssuesListCall) Filter(key, value string) *IssuesListCall {
-	c.options[key] = value
+	c.filters[key] = value
+	return c
+}
+
+func (c *IssuesListCall) Attachments(attachments bool) *IssuesListCall {
+	c.options["attachments"] = attachments
+	return c
+}
+
+func (c *IssuesListCall) Relations(relations bool) *IssuesListCall {
+	c.options["relations"] = relations
 	return c
 }
 
@@ -477,6 +756,19 @@ func (c *IssuesListCall) Do() (*IssueFeed, error) {
 			params.Set(opt, fmt.Sprintf("%v", v))
 		}
 	}
+	var include []string
+	for _, inc := range []string{"attachments", "relations"} {
+		v, ok := c.options[inc]
+		if !ok {
+			continue
+		}
+		if b, ok := v.(bool); ok && b {
+			include = append(include, inc)
+		}
+	}
+	if len(include) > 0 {
+		params.Set("include", strings.Join(include, ","))
+	}
 	for k, v := range c.filters {
 		params.Set(k, v)
 	}
@@ -494,6 +786,47 @@ func (c *IssuesListCall) Do() (*IssueFeed, error) {
 	return ret, nil
 }
 
+// Pages calls f for each page of issues, starting at the configured offset.
+// Iteration stops when f returns an error, which is then returned.
+func (c *IssuesListCall) Pages(f func(*IssueFeed) error) error {
+	offset, _ := c.options["offset"].(int)
+	if _, ok := c.options["limit"]; !ok {
+		c.options["limit"] = 100
+	}
+	for {
+		c.options["offset"] = offset
+		feed, err := c.Do()
+		if err != nil {
+			return err
+		}
+		err = f(feed)
+		if err != nil {
+			return err
+		}
+		offset += len(feed.Issues)
+		if len(feed.Issues) == 0 || offset >= feed.TotalCount {
+			return nil
+		}
+	}
+}
+
+// listAll returns all the issues matching the filters.
+func (r *IssuesService) listAll(filters map[string]string) ([]*Issue, error) {
+	var issues []*Issue
+	call := r.List()
+	for k, v := range filters {
+		call.Filter(k, v)
+	}
+	err := call.Pages(func(feed *IssueFeed) error {
+		issues = append(issues, feed.Issues...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
 //-------------------------------------------------------------------------
 // get issue
 //-------------------------------------------------------------------------
@@ -537,12 +870,10 @@ func (c *IssuesGetCall) Journals(journals bool) *IssuesGetCall {
 	return c
 }
 
-/*
 func (c *IssuesGetCall) Watchers(watchers bool) *IssuesGetCall {
 	c.options["watchers"] = watchers
 	return c
 }
-*/
 
 func (c *IssuesGetCall) Do() (*Issue, error) {
 	params := make(url.Values)
@@ -667,6 +998,184 @@ func (c *IssuesDeleteCall) Do() error {
 	return err
 }
 
+//-------------------------------------------------------------------------
+// attach files to issue
+//-------------------------------------------------------------------------
+
+// AttachFile is a file to attach to an issue. Either Path or Reader must be
+// set. When Reader is used, Size must hold its length and Filename the
+// name to show in redmine.
+type AttachFile struct {
+	Path        string
+	Reader      io.Reader
+	Size        int64
+	Filename    string
+	Description string
+}
+
+type AttachFileError struct {
+	File *AttachFile
+	Err  error
+}
+
+func (e *AttachFileError) Error() string {
+	name := e.File.Filename
+	if name == "" {
+		name = e.File.Path
+	}
+	return fmt.Sprintf("%v: %v", name, e.Err)
+}
+
+// AttachFilesError is returned by IssuesAttachFilesCall.Do when some of the
+// files could not be uploaded. The other files are still attached.
+type AttachFilesError []*AttachFileError
+
+func (e AttachFilesError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type IssuesAttachFilesCall struct {
+	s           *Service
+	issueId     int
+	files       []*AttachFile
+	notes       string
+	concurrency int
+	progress    func(file *AttachFile, written, total int64)
+	progressMu  sync.Mutex
+}
+
+func (r *IssuesService) AttachFiles(issueId int, files ...*AttachFile) *IssuesAttachFilesCall {
+	return &IssuesAttachFilesCall{
+		s:           r.s,
+		issueId:     issueId,
+		files:       files,
+		concurrency: 4,
+	}
+}
+
+func (c *IssuesAttachFilesCall) Notes(notes string) *IssuesAttachFilesCall {
+	c.notes = notes
+	return c
+}
+
+// Concurrency sets how many files are uploaded at the same time.
+func (c *IssuesAttachFilesCall) Concurrency(concurrency int) *IssuesAttachFilesCall {
+	c.concurrency = concurrency
+	return c
+}
+
+// Progress registers a callback that is invoked as the files are sent. The
+// files are uploaded concurrently, but the calls are serialized, so the
+// callback needs no locking of its own.
+func (c *IssuesAttachFilesCall) Progress(progress func(file *AttachFile, written, total int64)) *IssuesAttachFilesCall {
+	c.progress = progress
+	return c
+}
+
+// Do uploads the files and attaches them to the issue in a single update
+// that leaves the other issue fields untouched. It returns the attached
+// uploads; per-file failures are reported through an AttachFilesError.
+// Nothing is uploaded when a file has a Reader but no Size.
+func (c *IssuesAttachFilesCall) Do() ([]*Upload, error) {
+	for _, file := range c.files {
+		if file.Reader != nil && file.Size <= 0 {
+			return nil, &AttachFileError{file, errors.New("size required with a reader")}
+		}
+	}
+	uploads := make([]*Upload, len(c.files))
+	errs := make([]error, len(c.files))
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, file := range c.files {
+		wg.Add(1)
+		go func(i int, file *AttachFile) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			uploads[i], errs[i] = c.upload(file)
+		}(i, file)
+	}
+	wg.Wait()
+	var attached []*Upload
+	var failed AttachFilesError
+	for i, file := range c.files {
+		if errs[i] != nil {
+			failed = append(failed, &AttachFileError{file, errs[i]})
+			continue
+		}
+		attached = append(attached, uploads[i])
+	}
+	if len(attached) > 0 {
+		v := struct {
+			Issue struct {
+				Notes   string    `json:"notes,omitempty"`
+				Uploads []*Upload `json:"uploads"`
+			} `json:"issue"`
+		}{}
+		v.Issue.Notes = c.notes
+		v.Issue.Uploads = attached
+		body := new(bytes.Buffer)
+		err := json.NewEncoder(body).Encode(&v)
+		if err != nil {
+			return nil, err
+		}
+		urlStr := resolveRelative(c.s.baseUrl, "issues/{issueId}.json")
+		urlStr = strings.Replace(urlStr, "{issueId}", strconv.Itoa(c.issueId), 1)
+		_, err = c.s.doRequest("PUT", urlStr, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(failed) > 0 {
+		return attached, failed
+	}
+	return attached, nil
+}
+
+func (c *IssuesAttachFilesCall) upload(file *AttachFile) (*Upload, error) {
+	r := file.Reader
+	size := file.Size
+	filename := file.Filename
+	if r == nil {
+		f, err := os.Open(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		r = f
+		size = fi.Size()
+		if filename == "" {
+			filename = filepath.Base(file.Path)
+		}
+	}
+	call := c.s.Uploads.UploadReader(r, size).Filename(filename)
+	if c.progress != nil {
+		call.Progress(func(written, total int64) {
+			c.progressMu.Lock()
+			defer c.progressMu.Unlock()
+			c.progress(file, written, total)
+		})
+	}
+	upload, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+	upload.Description = file.Description
+	return upload, nil
+}
+
 //-------------------------------------------------------------------------
 // projects
 //-------------------------------------------------------------------------
@@ -678,27 +1187,47 @@ type ProjectFeed struct {
 	Offset     int        `json:"offset"`
 }
 
+// Project is a redmine project. IsPublic and InheritMembers are not sent
+// by the insert and update calls, since false cannot be told from unset;
+// use the setters of the calls instead.
 type Project struct {
-	Id              int            `json:"id"`
-	Identifier      string         `json:"identifier"`
-	Name            string         `json:"name"`
-	Description     string         `json:"description"`
-	Homepage        string         `json:"homepage"`
-	Parent          *Name          `json:"parent"`
-	Trackers        []*Name        `json:"trackers"`
-	IssueCategories []*Name        `json:"issue_categories"`
-	CustomFields    []*CustomField `json:"custom_fields"`
-	CreatedOn       string         `json:"created_on"`
-	UpdatedOn       string         `json:"updated_on"`
-}
+	Id                  int            `json:"id"`
+	Identifier          string         `json:"identifier"`
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	Homepage            string         `json:"homepage"`
+	Status              int            `json:"status"`
+	IsPublic            bool           `json:"is_public"`
+	InheritMembers      bool           `json:"inherit_members"`
+	Parent              *Name          `json:"parent"`
+	Trackers            []*Name        `json:"trackers"`
+	IssueCategories     []*Name        `json:"issue_categories"`
+	EnabledModules      []*Name        `json:"enabled_modules"`
+	TimeEntryActivities []*Name        `json:"time_entry_activities"`
+	IssueCustomFields   []*Name        `json:"issue_custom_fields"`
+	CustomFields        []*CustomField `json:"custom_fields"`
+	CreatedOn           string         `json:"created_on"`
+	UpdatedOn           string         `json:"updated_on"`
+}
+
+const (
+	ProjectStatusActive   = 1
+	ProjectStatusClosed   = 5
+	ProjectStatusArchived = 9
+)
 
 type project struct {
-	Identifier   string         `json:"identifier,omitempty"`
-	Name         string         `json:"name,omitempty"`
-	Description  string         `json:"description,omitempty"`
-	Homepage     string         `json:"homepage,omitempty"`
-	ParentId     int            `json:"parent_id,omitempty"`
-	CustomFields []*customField `json:"custom_fields,omitempty"`
+	Identifier          string         `json:"identifier,omitempty"`
+	Name                string         `json:"name,omitempty"`
+	Description         string         `json:"description,omitempty"`
+	Homepage            string         `json:"homepage,omitempty"`
+	IsPublic            *bool          `json:"is_public,omitempty"`
+	InheritMembers      *bool          `json:"inherit_members,omitempty"`
+	ParentId            int            `json:"parent_id,omitempty"`
+	EnabledModuleNames  []string       `json:"enabled_module_names,omitempty"`
+	TrackerIds          []int          `json:"tracker_ids,omitempty"`
+	IssueCustomFieldIds []int          `json:"issue_custom_field_ids,omitempty"`
+	CustomFields        []*customField `json:"custom_fields,omitempty"`
 }
 
 func (r *Project) toSend() *project {
@@ -710,13 +1239,28 @@ func (r *Project) toSend() *project {
 	if r.Parent != nil {
 		newProject.ParentId = r.Parent.Id
 	}
+	if r.EnabledModules != nil {
+		newProject.EnabledModuleNames = make([]string, len(r.EnabledModules))
+		for i, module := range r.EnabledModules {
+			newProject.EnabledModuleNames[i] = module.Name
+		}
+	}
+	if r.Trackers != nil {
+		newProject.TrackerIds = make([]int, len(r.Trackers))
+		for i, tracker := range r.Trackers {
+			newProject.TrackerIds[i] = tracker.Id
+		}
+	}
+	if r.IssueCustomFields != nil {
+		newProject.IssueCustomFieldIds = make([]int, len(r.IssueCustomFields))
+		for i, cf := range r.IssueCustomFields {
+			newProject.IssueCustomFieldIds[i] = cf.Id
+		}
+	}
 	if r.CustomFields != nil {
 		newProject.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newProject.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newProject.CustomFields[i] = cf.toSend()
 		}
 	}
 	return newProject
@@ -748,6 +1292,16 @@ func (c *ProjectsListCall) Limit(limit int) *ProjectsListCall {
 	return c
 }
 
+func (c *ProjectsListCall) EnabledModules(enabledModules bool) *ProjectsListCall {
+	c.options["enabled_modules"] = enabledModules
+	return c
+}
+
+func (c *ProjectsListCall) TimeEntryActivities(timeEntryActivities bool) *ProjectsListCall {
+	c.options["time_entry_activities"] = timeEntryActivities
+	return c
+}
+
 func (c *ProjectsListCall) Do() (*ProjectFeed, error) {
 	params := make(url.Values)
 	for _, opt := range []string{"offset", "limit"} {
@@ -755,6 +1309,19 @@ func (c *ProjectsListCall) Do() (*ProjectFeed, error) {
 			params.Set(opt, fmt.Sprintf("%v", v))
 		}
 	}
+	var include []string
+	for _, inc := range []string{"enabled_modules", "time_entry_activities"} {
+		v, ok := c.options[inc]
+		if !ok {
+			continue
+		}
+		if b, ok := v.(bool); ok && b {
+			include = append(include, inc)
+		}
+	}
+	if len(include) > 0 {
+		params.Set("include", strings.Join(include, ","))
+	}
 	urlStr := resolveRelative(c.s.baseUrl, "projects.json")
 	urlStr += "?" + params.Encode()
 	data, err := c.s.doRequest("GET", urlStr, nil)
@@ -774,16 +1341,16 @@ func (c *ProjectsListCall) Do() (*ProjectFeed, error) {
 //-------------------------------------------------------------------------
 
 type ProjectsGetCall struct {
-	s         *Service
-	projectId int
-	options   map[string]interface{}
+	s       *Service
+	project ProjectRef
+	options map[string]interface{}
 }
 
-func (r *ProjectsService) Get(projectId int) *ProjectsGetCall {
+func (r *ProjectsService) Get(project ProjectRef) *ProjectsGetCall {
 	return &ProjectsGetCall{
-		s:         r.s,
-		projectId: projectId,
-		options:   make(map[string]interface{}),
+		s:       r.s,
+		project: project,
+		options: make(map[string]interface{}),
 	}
 }
 
@@ -797,10 +1364,20 @@ func (c *ProjectsGetCall) IssueCategories(issueCategories bool) *ProjectsGetCall
 	return c
 }
 
+func (c *ProjectsGetCall) EnabledModules(enabledModules bool) *ProjectsGetCall {
+	c.options["enabled_modules"] = enabledModules
+	return c
+}
+
+func (c *ProjectsGetCall) TimeEntryActivities(timeEntryActivities bool) *ProjectsGetCall {
+	c.options["time_entry_activities"] = timeEntryActivities
+	return c
+}
+
 func (c *ProjectsGetCall) Do() (*Project, error) {
 	params := make(url.Values)
 	var include []string
-	for _, inc := range []string{"trackers", "issue_categories"} {
+	for _, inc := range []string{"trackers", "issue_categories", "enabled_modules", "time_entry_activities"} {
 		v, ok := c.options[inc]
 		if !ok {
 			continue
@@ -812,8 +1389,10 @@ func (c *ProjectsGetCall) Do() (*Project, error) {
 	if len(include) > 0 {
 		params.Set("include", strings.Join(include, ","))
 	}
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
 	urlStr += "?" + params.Encode()
 	data, err := c.s.doRequest("GET", urlStr, nil)
 	if err != nil {
@@ -834,19 +1413,39 @@ func (c *ProjectsGetCall) Do() (*Project, error) {
 //-------------------------------------------------------------------------
 
 type ProjectsInsertCall struct {
-	s       *Service
-	project *Project
+	s              *Service
+	project        *Project
+	isPublic       *bool
+	inheritMembers *bool
 }
 
 func (r *ProjectsService) Insert(project *Project) *ProjectsInsertCall {
-	return &ProjectsInsertCall{r.s, project}
+	return &ProjectsInsertCall{
+		s:       r.s,
+		project: project,
+	}
 }
 
-func (c *ProjectsInsertCall) Do() (*Project, error) {
+// IsPublic sets the visibility of the project. When not called, redmine
+// applies its "public by default" setting.
+func (c *ProjectsInsertCall) IsPublic(isPublic bool) *ProjectsInsertCall {
+	c.isPublic = &isPublic
+	return c
+}
+
+func (c *ProjectsInsertCall) InheritMembers(inheritMembers bool) *ProjectsInsertCall {
+	c.inheritMembers = &inheritMembers
+	return c
+}
+
+func (c *ProjectsInsertCall) Do() (*Project, error) {
+	p := c.project.toSend()
+	p.IsPublic = c.isPublic
+	p.InheritMembers = c.inheritMembers
 	v := struct {
 		Project *project `json:"project,omitempty"`
 	}{
-		c.project.toSend(),
+		p,
 	}
 	body := new(bytes.Buffer)
 	err := json.NewEncoder(body).Encode(&v)
@@ -873,19 +1472,39 @@ func (c *ProjectsInsertCall) Do() (*Project, error) {
 //-------------------------------------------------------------------------
 
 type ProjectsUpdateCall struct {
-	s       *Service
-	project *Project
+	s              *Service
+	project        *Project
+	isPublic       *bool
+	inheritMembers *bool
 }
 
 func (r *ProjectsService) Update(project *Project) *ProjectsUpdateCall {
-	return &ProjectsUpdateCall{r.s, project}
+	return &ProjectsUpdateCall{
+		s:       r.s,
+		project: project,
+	}
+}
+
+// IsPublic changes the visibility of the project, which is left as is
+// when not called.
+func (c *ProjectsUpdateCall) IsPublic(isPublic bool) *ProjectsUpdateCall {
+	c.isPublic = &isPublic
+	return c
+}
+
+func (c *ProjectsUpdateCall) InheritMembers(inheritMembers bool) *ProjectsUpdateCall {
+	c.inheritMembers = &inheritMembers
+	return c
 }
 
 func (c *ProjectsUpdateCall) Do() error {
+	p := c.project.toSend()
+	p.IsPublic = c.isPublic
+	p.InheritMembers = c.inheritMembers
 	v := struct {
 		Project *project `json:"project,omitempty"`
 	}{
-		c.project.toSend(),
+		p,
 	}
 	body := new(bytes.Buffer)
 	err := json.NewEncoder(body).Encode(&v)
@@ -903,18 +1522,108 @@ func (c *ProjectsUpdateCall) Do() error {
 //-------------------------------------------------------------------------
 
 type ProjectsDeleteCall struct {
-	s         *Service
-	projectId int
+	s       *Service
+	project ProjectRef
 }
 
-func (r *ProjectsService) Delete(projectId int) *ProjectsDeleteCall {
-	return &ProjectsDeleteCall{r.s, projectId}
+func (r *ProjectsService) Delete(project ProjectRef) *ProjectsDeleteCall {
+	return &ProjectsDeleteCall{r.s, project}
 }
 
 func (c *ProjectsDeleteCall) Do() error {
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
-	_, err := c.s.doRequest("DELETE", urlStr, nil)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("DELETE", urlStr, nil)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// archive project
+//-------------------------------------------------------------------------
+
+type ProjectsArchiveCall struct {
+	s       *Service
+	project ProjectRef
+}
+
+func (r *ProjectsService) Archive(project ProjectRef) *ProjectsArchiveCall {
+	return &ProjectsArchiveCall{r.s, project}
+}
+
+func (c *ProjectsArchiveCall) Do() error {
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/archive.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("PUT", urlStr, nil)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// unarchive project
+//-------------------------------------------------------------------------
+
+type ProjectsUnarchiveCall struct {
+	s       *Service
+	project ProjectRef
+}
+
+func (r *ProjectsService) Unarchive(project ProjectRef) *ProjectsUnarchiveCall {
+	return &ProjectsUnarchiveCall{r.s, project}
+}
+
+func (c *ProjectsUnarchiveCall) Do() error {
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/unarchive.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("PUT", urlStr, nil)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// close project
+//-------------------------------------------------------------------------
+
+type ProjectsCloseCall struct {
+	s       *Service
+	project ProjectRef
+}
+
+func (r *ProjectsService) Close(project ProjectRef) *ProjectsCloseCall {
+	return &ProjectsCloseCall{r.s, project}
+}
+
+func (c *ProjectsCloseCall) Do() error {
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/close.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("PUT", urlStr, nil)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// reopen project
+//-------------------------------------------------------------------------
+
+type ProjectsReopenCall struct {
+	s       *Service
+	project ProjectRef
+}
+
+func (r *ProjectsService) Reopen(project ProjectRef) *ProjectsReopenCall {
+	return &ProjectsReopenCall{r.s, project}
+}
+
+func (c *ProjectsReopenCall) Do() error {
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/reopen.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("PUT", urlStr, nil)
 	return err
 }
 
@@ -967,16 +1676,16 @@ func (r *Membership) toSend() *membership {
 //-------------------------------------------------------------------------
 
 type MembershipsListCall struct {
-	s         *Service
-	projectId int
-	options   map[string]interface{}
+	s       *Service
+	project ProjectRef
+	options map[string]interface{}
 }
 
-func (r *MembershipsService) List(projectId int) *MembershipsListCall {
+func (r *MembershipsService) List(project ProjectRef) *MembershipsListCall {
 	return &MembershipsListCall{
-		s:         r.s,
-		projectId: projectId,
-		options:   make(map[string]interface{}),
+		s:       r.s,
+		project: project,
+		options: make(map[string]interface{}),
 	}
 }
 
@@ -997,8 +1706,10 @@ func (c *MembershipsListCall) Do() (*MembershipFeed, error) {
 			params.Set(opt, fmt.Sprintf("%v", v))
 		}
 	}
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/memberships.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/memberships.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
 	urlStr += "?" + params.Encode()
 	data, err := c.s.doRequest("GET", urlStr, nil)
 	if err != nil {
@@ -1143,19 +1854,43 @@ type UserFeed struct {
 	Offset     int     `json:"offset"`
 }
 
+// User is a redmine user. Admin and MustChangePasswd are not sent by the
+// insert and update calls, since false cannot be told from unset; use the
+// setters of the calls instead.
 type User struct {
-	Id           int               `json:"id"`
-	Login        string            `json:"login"`
-	Password     string            `json:"password"`
-	Firstname    string            `json:"firstname"`
-	Lastname     string            `json:"lastname"`
-	Name         string            `json:"name"`
-	Mail         string            `json:"mail"`
-	Memberships  []*UserMembership `json:"memberships"`
-	Groups       []*Name           `json:"groups"`
-	CustomFields []*CustomField    `json:"custom_fields"`
-	CreatedOn    string            `json:"created_on"`
-	LastLoginOn  string            `json:"last_login_on"`
+	Id               int               `json:"id"`
+	Login            string            `json:"login"`
+	Password         string            `json:"password"`
+	Firstname        string            `json:"firstname"`
+	Lastname         string            `json:"lastname"`
+	Name             string            `json:"name"`
+	Mail             string            `json:"mail"`
+	Admin            bool              `json:"admin"`
+	Status           int               `json:"status"`
+	AuthSourceId     int               `json:"auth_source_id"`
+	MailNotification string            `json:"mail_notification"`
+	MustChangePasswd bool              `json:"must_change_passwd"`
+	ApiKey           string            `json:"api_key"`
+	Pref             *UserPreferences  `json:"pref"`
+	Memberships      []*UserMembership `json:"memberships"`
+	Groups           []*Name           `json:"groups"`
+	CustomFields     []*CustomField    `json:"custom_fields"`
+	CreatedOn        string            `json:"created_on"`
+	LastLoginOn      string            `json:"last_login_on"`
+}
+
+const (
+	UserStatusActive     = 1
+	UserStatusRegistered = 2
+	UserStatusLocked     = 3
+)
+
+type UserPreferences struct {
+	HideMail             bool   `json:"hide_mail"`
+	TimeZone             string `json:"time_zone"`
+	CommentsSorting      string `json:"comments_sorting"`
+	WarnOnLeavingUnsaved bool   `json:"warn_on_leaving_unsaved"`
+	NoSelfNotified       bool   `json:"no_self_notified"`
 }
 
 type UserMembership struct {
@@ -1164,12 +1899,17 @@ type UserMembership struct {
 }
 
 type user struct {
-	Login        string         `json:"login,omitempty"`
-	Password     string         `json:"password,omitempty"`
-	Firstname    string         `json:"firstname,omitempty"`
-	Lastname     string         `json:"lastname,omitempty"`
-	Mail         string         `json:"mail,omitempty"`
-	CustomFields []*customField `json:"custom_fields,omitempty"`
+	Login            string         `json:"login,omitempty"`
+	Password         string         `json:"password,omitempty"`
+	Firstname        string         `json:"firstname,omitempty"`
+	Lastname         string         `json:"lastname,omitempty"`
+	Mail             string         `json:"mail,omitempty"`
+	Admin            *bool          `json:"admin,omitempty"`
+	Status           int            `json:"status,omitempty"`
+	AuthSourceId     int            `json:"auth_source_id,omitempty"`
+	MailNotification string         `json:"mail_notification,omitempty"`
+	MustChangePasswd *bool          `json:"must_change_passwd,omitempty"`
+	CustomFields     []*customField `json:"custom_fields,omitempty"`
 }
 
 func (r *User) toSend() *user {
@@ -1179,13 +1919,13 @@ func (r *User) toSend() *user {
 	newUser.Firstname = r.Firstname
 	newUser.Lastname = r.Lastname
 	newUser.Mail = r.Mail
+	newUser.Status = r.Status
+	newUser.AuthSourceId = r.AuthSourceId
+	newUser.MailNotification = r.MailNotification
 	if r.CustomFields != nil {
 		newUser.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newUser.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newUser.CustomFields[i] = cf.toSend()
 		}
 	}
 	return newUser
@@ -1217,9 +1957,24 @@ func (c *UsersListCall) Limit(limit int) *UsersListCall {
 	return c
 }
 
+func (c *UsersListCall) Status(status int) *UsersListCall {
+	c.options["status"] = status
+	return c
+}
+
+func (c *UsersListCall) Name(name string) *UsersListCall {
+	c.options["name"] = name
+	return c
+}
+
+func (c *UsersListCall) GroupId(groupId int) *UsersListCall {
+	c.options["group_id"] = groupId
+	return c
+}
+
 func (c *UsersListCall) Do() (*UserFeed, error) {
 	params := make(url.Values)
-	for _, opt := range []string{"offset", "limit"} {
+	for _, opt := range []string{"offset", "limit", "status", "name", "group_id"} {
 		if v, ok := c.options[opt]; ok {
 			params.Set(opt, fmt.Sprintf("%v", v))
 		}
@@ -1298,24 +2053,107 @@ func (c *UsersGetCall) Do() (*User, error) {
 	return ret.User, nil
 }
 
+//-------------------------------------------------------------------------
+// get current user
+//-------------------------------------------------------------------------
+
+type UsersCurrentCall struct {
+	s       *Service
+	options map[string]interface{}
+}
+
+func (r *UsersService) Current() *UsersCurrentCall {
+	return &UsersCurrentCall{
+		s:       r.s,
+		options: make(map[string]interface{}),
+	}
+}
+
+func (c *UsersCurrentCall) Memberships(memberships bool) *UsersCurrentCall {
+	c.options["memberships"] = memberships
+	return c
+}
+
+func (c *UsersCurrentCall) Groups(groups bool) *UsersCurrentCall {
+	c.options["groups"] = groups
+	return c
+}
+
+func (c *UsersCurrentCall) Do() (*User, error) {
+	params := make(url.Values)
+	var include []string
+	for _, inc := range []string{"memberships", "groups"} {
+		v, ok := c.options[inc]
+		if !ok {
+			continue
+		}
+		if b, ok := v.(bool); ok && b {
+			include = append(include, inc)
+		}
+	}
+	if len(include) > 0 {
+		params.Set("include", strings.Join(include, ","))
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "users/current.json")
+	urlStr += "?" + params.Encode()
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		User *User `json:"user"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.User, nil
+}
+
 //-------------------------------------------------------------------------
 // insert user
 //-------------------------------------------------------------------------
 
 type UsersInsertCall struct {
-	s    *Service
-	user *User
+	s                *Service
+	user             *User
+	sendInformation  bool
+	admin            *bool
+	mustChangePasswd *bool
 }
 
 func (r *UsersService) Insert(user *User) *UsersInsertCall {
-	return &UsersInsertCall{r.s, user}
+	return &UsersInsertCall{
+		s:    r.s,
+		user: user,
+	}
+}
+
+func (c *UsersInsertCall) SendInformation(sendInformation bool) *UsersInsertCall {
+	c.sendInformation = sendInformation
+	return c
+}
+
+func (c *UsersInsertCall) Admin(admin bool) *UsersInsertCall {
+	c.admin = &admin
+	return c
+}
+
+func (c *UsersInsertCall) MustChangePasswd(mustChangePasswd bool) *UsersInsertCall {
+	c.mustChangePasswd = &mustChangePasswd
+	return c
 }
 
 func (c *UsersInsertCall) Do() (*User, error) {
+	u := c.user.toSend()
+	u.Admin = c.admin
+	u.MustChangePasswd = c.mustChangePasswd
 	v := struct {
-		User *user `json:"user,omitempty"`
+		User            *user `json:"user,omitempty"`
+		SendInformation bool  `json:"send_information,omitempty"`
 	}{
-		c.user.toSend(),
+		u,
+		c.sendInformation,
 	}
 	body := new(bytes.Buffer)
 	err := json.NewEncoder(body).Encode(&v)
@@ -1342,19 +2180,39 @@ func (c *UsersInsertCall) Do() (*User, error) {
 //-------------------------------------------------------------------------
 
 type UsersUpdateCall struct {
-	s    *Service
-	user *User
+	s                *Service
+	user             *User
+	admin            *bool
+	mustChangePasswd *bool
 }
 
 func (r *UsersService) Update(user *User) *UsersUpdateCall {
-	return &UsersUpdateCall{r.s, user}
+	return &UsersUpdateCall{
+		s:    r.s,
+		user: user,
+	}
+}
+
+// Admin grants or revokes the administration rights, which are left as is
+// when not called.
+func (c *UsersUpdateCall) Admin(admin bool) *UsersUpdateCall {
+	c.admin = &admin
+	return c
+}
+
+func (c *UsersUpdateCall) MustChangePasswd(mustChangePasswd bool) *UsersUpdateCall {
+	c.mustChangePasswd = &mustChangePasswd
+	return c
 }
 
 func (c *UsersUpdateCall) Do() error {
+	u := c.user.toSend()
+	u.Admin = c.admin
+	u.MustChangePasswd = c.mustChangePasswd
 	v := struct {
 		User *user `json:"user,omitempty"`
 	}{
-		c.user.toSend(),
+		u,
 	}
 	body := new(bytes.Buffer)
 	err := json.NewEncoder(body).Encode(&v)
@@ -1367,6 +2225,91 @@ func (c *UsersUpdateCall) Do() error {
 	return err
 }
 
+//-------------------------------------------------------------------------
+// lock user
+//-------------------------------------------------------------------------
+
+type UsersLockCall struct {
+	s      *Service
+	userId int
+}
+
+func (r *UsersService) Lock(userId int) *UsersLockCall {
+	return &UsersLockCall{r.s, userId}
+}
+
+func (c *UsersLockCall) Do() error {
+	return c.s.setUserStatus(c.userId, UserStatusLocked)
+}
+
+//-------------------------------------------------------------------------
+// unlock user
+//-------------------------------------------------------------------------
+
+type UsersUnlockCall struct {
+	s      *Service
+	userId int
+}
+
+func (r *UsersService) Unlock(userId int) *UsersUnlockCall {
+	return &UsersUnlockCall{r.s, userId}
+}
+
+func (c *UsersUnlockCall) Do() error {
+	return c.s.setUserStatus(c.userId, UserStatusActive)
+}
+
+func (s *Service) setUserStatus(userId, status int) error {
+	v := struct {
+		User struct {
+			Status int `json:"status"`
+		} `json:"user"`
+	}{}
+	v.User.Status = status
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(s.baseUrl, "users/{userId}.json")
+	urlStr = strings.Replace(urlStr, "{userId}", strconv.Itoa(userId), 1)
+	_, err = s.doRequest("PUT", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// send account information
+//-------------------------------------------------------------------------
+
+// note: redmine only mails active users, and never the user making the call
+
+type UsersSendInformationCall struct {
+	s      *Service
+	userId int
+}
+
+func (r *UsersService) SendInformation(userId int) *UsersSendInformationCall {
+	return &UsersSendInformationCall{r.s, userId}
+}
+
+func (c *UsersSendInformationCall) Do() error {
+	v := struct {
+		User            struct{} `json:"user"`
+		SendInformation bool     `json:"send_information"`
+	}{
+		SendInformation: true,
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "users/{userId}.json")
+	urlStr = strings.Replace(urlStr, "{userId}", strconv.Itoa(c.userId), 1)
+	_, err = c.s.doRequest("PUT", urlStr, body)
+	return err
+}
+
 //-------------------------------------------------------------------------
 // delete user
 //-------------------------------------------------------------------------
@@ -1443,10 +2386,7 @@ func (r *TimeEntry) toSend() *timeEntry {
 	if r.CustomFields != nil {
 		newTimeEntry.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newTimeEntry.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newTimeEntry.CustomFields[i] = cf.toSend()
 		}
 	}
 	return newTimeEntry
@@ -1458,6 +2398,7 @@ func (r *TimeEntry) toSend() *timeEntry {
 
 type TimeEntriesListCall struct {
 	s       *Service
+	project ProjectRef
 	options map[string]interface{}
 }
 
@@ -1478,30 +2419,104 @@ func (c *TimeEntriesListCall) Limit(limit int) *TimeEntriesListCall {
 	return c
 }
 
-func (c *TimeEntriesListCall) Do() (*TimeEntryFeed, error) {
-	params := make(url.Values)
-	for _, opt := range []string{"offset", "limit"} {
-		if v, ok := c.options[opt]; ok {
-			params.Set(opt, fmt.Sprintf("%v", v))
-		}
-	}
-	urlStr := resolveRelative(c.s.baseUrl, "time_entries.json")
-	urlStr += "?" + params.Encode()
-	data, err := c.s.doRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
-	}
-	ret := new(TimeEntryFeed)
-	err = json.Unmarshal(data, &ret)
-	if err != nil {
-		return nil, err
-	}
-	return ret, nil
+func (c *TimeEntriesListCall) Project(project ProjectRef) *TimeEntriesListCall {
+	c.project = project
+	return c
+}
+
+func (c *TimeEntriesListCall) IssueId(issueId int) *TimeEntriesListCall {
+	c.options["issue_id"] = issueId
+	return c
+}
+
+func (c *TimeEntriesListCall) UserId(userId int) *TimeEntriesListCall {
+	c.options["user_id"] = userId
+	return c
+}
+
+// Me restricts the entries to the ones logged by the current user.
+func (c *TimeEntriesListCall) Me() *TimeEntriesListCall {
+	c.options["user_id"] = "me"
+	return c
+}
+
+func (c *TimeEntriesListCall) ActivityId(activityId int) *TimeEntriesListCall {
+	c.options["activity_id"] = activityId
+	return c
+}
+
+func (c *TimeEntriesListCall) From(from time.Time) *TimeEntriesListCall {
+	c.options["from"] = from.Format(DateLayout)
+	return c
+}
+
+func (c *TimeEntriesListCall) To(to time.Time) *TimeEntriesListCall {
+	c.options["to"] = to.Format(DateLayout)
+	return c
+}
+
+func (c *TimeEntriesListCall) SpentOn(spentOn time.Time) *TimeEntriesListCall {
+	c.options["spent_on"] = spentOn.Format(DateLayout)
+	return c
+}
+
+func (c *TimeEntriesListCall) Do() (*TimeEntryFeed, error) {
+	params := make(url.Values)
+	for _, opt := range []string{"offset", "limit", "issue_id", "user_id", "activity_id", "from", "to", "spent_on"} {
+		if v, ok := c.options[opt]; ok {
+			params.Set(opt, fmt.Sprintf("%v", v))
+		}
+	}
+	var urlStr string
+	var err error
+	if c.project == nil {
+		urlStr = resolveRelative(c.s.baseUrl, "time_entries.json")
+	} else {
+		urlStr, err = expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/time_entries.json"), c.project)
+		if err != nil {
+			return nil, err
+		}
+	}
+	urlStr += "?" + params.Encode()
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := new(TimeEntryFeed)
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Pages calls f for each page of time entries, starting at the configured
+// offset. Iteration stops when f returns an error, which is then returned.
+func (c *TimeEntriesListCall) Pages(f func(*TimeEntryFeed) error) error {
+	offset, _ := c.options["offset"].(int)
+	if _, ok := c.options["limit"]; !ok {
+		c.options["limit"] = 100
+	}
+	for {
+		c.options["offset"] = offset
+		feed, err := c.Do()
+		if err != nil {
+			return err
+		}
+		err = f(feed)
+		if err != nil {
+			return err
+		}
+		offset += len(feed.TimeEntries)
+		if len(feed.TimeEntries) == 0 || offset >= feed.TotalCount {
+			return nil
+		}
+	}
 }
 
 //-------------------------------------------------------------------------
-// get time entry
-//-------------------------------------------------------------------------
+// get time entry
+//-------------------------------------------------------------------------
 
 type TimeEntriesGetCall struct {
 	s           *Service
@@ -1630,13 +2645,37 @@ type NewsFeed struct {
 }
 
 type News struct {
-	Id          int    `json:"id"`
-	Title       string `json:"title"`
-	Summary     string `json:"summary"`
-	Description string `json:"description"`
-	Project     *Name  `json:"project"`
-	Author      *Name  `json:"author"`
-	CreatedOn   string `json:"created_on"`
+	Id          int            `json:"id"`
+	Title       string         `json:"title"`
+	Summary     string         `json:"summary"`
+	Description string         `json:"description"`
+	Project     *Name          `json:"project"`
+	Author      *Name          `json:"author"`
+	Comments    []*NewsComment `json:"comments"`
+	Attachments []*Attachment  `json:"attachments"`
+	CreatedOn   string         `json:"created_on"`
+}
+
+type NewsComment struct {
+	Id      int    `json:"id"`
+	Author  *Name  `json:"author"`
+	Content string `json:"content"`
+}
+
+type news struct {
+	Title       string    `json:"title,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Uploads     []*Upload `json:"uploads,omitempty"`
+}
+
+func (r *News) toSend(uploads []*Upload) *news {
+	newNews := new(news)
+	newNews.Title = r.Title
+	newNews.Summary = r.Summary
+	newNews.Description = r.Description
+	newNews.Uploads = uploads
+	return newNews
 }
 
 //-------------------------------------------------------------------------
@@ -1644,9 +2683,9 @@ type News struct {
 //-------------------------------------------------------------------------
 
 type NewsListCall struct {
-	s         *Service
-	projectId int
-	options   map[string]interface{}
+	s       *Service
+	project ProjectRef
+	options map[string]interface{}
 }
 
 func (r *NewsService) List() *NewsListCall {
@@ -1666,8 +2705,8 @@ func (c *NewsListCall) Limit(limit int) *NewsListCall {
 	return c
 }
 
-func (c *NewsListCall) Project(projectId int) *NewsListCall {
-	c.projectId = projectId
+func (c *NewsListCall) Project(project ProjectRef) *NewsListCall {
+	c.project = project
 	return c
 }
 
@@ -1679,11 +2718,14 @@ func (c *NewsListCall) Do() (*NewsFeed, error) {
 		}
 	}
 	var urlStr string
-	if c.projectId == 0 {
+	var err error
+	if c.project == nil {
 		urlStr = resolveRelative(c.s.baseUrl, "news.json")
 	} else {
-		urlStr = resolveRelative(c.s.baseUrl, "projects/{projectId}/news.json")
-		urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+		urlStr, err = expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/news.json"), c.project)
+		if err != nil {
+			return nil, err
+		}
 	}
 	urlStr += "?" + params.Encode()
 	data, err := c.s.doRequest("GET", urlStr, nil)
@@ -1698,6 +2740,151 @@ func (c *NewsListCall) Do() (*NewsFeed, error) {
 	return ret, nil
 }
 
+//-------------------------------------------------------------------------
+// get news
+//-------------------------------------------------------------------------
+
+type NewsGetCall struct {
+	s       *Service
+	newsId  int
+	options map[string]interface{}
+}
+
+func (r *NewsService) Get(newsId int) *NewsGetCall {
+	return &NewsGetCall{
+		s:       r.s,
+		newsId:  newsId,
+		options: make(map[string]interface{}),
+	}
+}
+
+func (c *NewsGetCall) Comments(comments bool) *NewsGetCall {
+	c.options["comments"] = comments
+	return c
+}
+
+func (c *NewsGetCall) Attachments(attachments bool) *NewsGetCall {
+	c.options["attachments"] = attachments
+	return c
+}
+
+func (c *NewsGetCall) Do() (*News, error) {
+	params := make(url.Values)
+	var include []string
+	for _, inc := range []string{"comments", "attachments"} {
+		v, ok := c.options[inc]
+		if !ok {
+			continue
+		}
+		if b, ok := v.(bool); ok && b {
+			include = append(include, inc)
+		}
+	}
+	if len(include) > 0 {
+		params.Set("include", strings.Join(include, ","))
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "news/{newsId}.json")
+	urlStr = strings.Replace(urlStr, "{newsId}", strconv.Itoa(c.newsId), 1)
+	urlStr += "?" + params.Encode()
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		News *News `json:"news"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.News, nil
+}
+
+//-------------------------------------------------------------------------
+// insert news
+//-------------------------------------------------------------------------
+
+type NewsInsertCall struct {
+	s       *Service
+	news    *News
+	project ProjectRef
+	uploads []*Upload
+}
+
+func (r *NewsService) Insert(news *News, project ProjectRef, uploads ...*Upload) *NewsInsertCall {
+	return &NewsInsertCall{r.s, news, project, uploads}
+}
+
+func (c *NewsInsertCall) Do() error {
+	v := struct {
+		News *news `json:"news,omitempty"`
+	}{
+		c.news.toSend(c.uploads),
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/news.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("POST", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// update news
+//-------------------------------------------------------------------------
+
+type NewsUpdateCall struct {
+	s       *Service
+	news    *News
+	uploads []*Upload
+}
+
+func (r *NewsService) Update(news *News, uploads ...*Upload) *NewsUpdateCall {
+	return &NewsUpdateCall{r.s, news, uploads}
+}
+
+func (c *NewsUpdateCall) Do() error {
+	v := struct {
+		News *news `json:"news,omitempty"`
+	}{
+		c.news.toSend(c.uploads),
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "news/{newsId}.json")
+	urlStr = strings.Replace(urlStr, "{newsId}", strconv.Itoa(c.news.Id), 1)
+	_, err = c.s.doRequest("PUT", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// delete news
+//-------------------------------------------------------------------------
+
+type NewsDeleteCall struct {
+	s      *Service
+	newsId int
+}
+
+func (r *NewsService) Delete(newsId int) *NewsDeleteCall {
+	return &NewsDeleteCall{r.s, newsId}
+}
+
+func (c *NewsDeleteCall) Do() error {
+	urlStr := resolveRelative(c.s.baseUrl, "news/{newsId}.json")
+	urlStr = strings.Replace(urlStr, "{newsId}", strconv.Itoa(c.newsId), 1)
+	_, err := c.s.doRequest("DELETE", urlStr, nil)
+	return err
+}
+
 //-------------------------------------------------------------------------
 // relations
 //-------------------------------------------------------------------------
@@ -1882,10 +3069,7 @@ func (r *Version) toSend() *version {
 	if r.CustomFields != nil {
 		newVersion.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newVersion.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newVersion.CustomFields[i] = cf.toSend()
 		}
 	}
 	return newVersion
@@ -1896,17 +3080,19 @@ func (r *Version) toSend() *version {
 //-------------------------------------------------------------------------
 
 type VersionsListCall struct {
-	s         *Service
-	projectId int
+	s       *Service
+	project ProjectRef
 }
 
-func (r *VersionsService) List(projectId int) *VersionsListCall {
-	return &VersionsListCall{r.s, projectId}
+func (r *VersionsService) List(project ProjectRef) *VersionsListCall {
+	return &VersionsListCall{r.s, project}
 }
 
 func (c *VersionsListCall) Do() ([]*Version, error) {
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/versions.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/versions.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
 	data, err := c.s.doRequest("GET", urlStr, nil)
 	if err != nil {
 		return nil, err
@@ -2078,17 +3264,19 @@ func (r *WikiPage) toSend() *wikiPage {
 //-------------------------------------------------------------------------
 
 type WikiListCall struct {
-	s         *Service
-	projectId int
+	s       *Service
+	project ProjectRef
 }
 
-func (r *WikiService) List(projectId int) *WikiListCall {
-	return &WikiListCall{r.s, projectId}
+func (r *WikiService) List(project ProjectRef) *WikiListCall {
+	return &WikiListCall{r.s, project}
 }
 
 func (c *WikiListCall) Do() ([]*WikiPage, error) {
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/index.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/index.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
 	data, err := c.s.doRequest("GET", urlStr, nil)
 	if err != nil {
 		return nil, err
@@ -2122,19 +3310,19 @@ func (c *WikiListCall) Do() ([]*WikiPage, error) {
 //-------------------------------------------------------------------------
 
 type WikiGetCall struct {
-	s         *Service
-	projectId int
-	title     string
-	version   int
-	options   map[string]interface{}
+	s       *Service
+	project ProjectRef
+	title   string
+	version int
+	options map[string]interface{}
 }
 
-func (r *WikiService) Get(projectId int, title string) *WikiGetCall {
+func (r *WikiService) Get(project ProjectRef, title string) *WikiGetCall {
 	return &WikiGetCall{
-		s:         r.s,
-		projectId: projectId,
-		title:     title,
-		options:   make(map[string]interface{}),
+		s:       r.s,
+		project: project,
+		title:   title,
+		options: make(map[string]interface{}),
 	}
 }
 
@@ -2170,7 +3358,10 @@ func (c *WikiGetCall) Do() (*WikiPage, error) {
 	} else {
 		urlStr = resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/{title}.json")
 	}
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(urlStr, c.project)
+	if err != nil {
+		return nil, err
+	}
 	urlStr = strings.Replace(urlStr, "{title}", c.title, 1)
 	urlStr += "?" + params.Encode()
 	data, err := c.s.doRequest("GET", urlStr, nil)
@@ -2192,13 +3383,13 @@ func (c *WikiGetCall) Do() (*WikiPage, error) {
 //-------------------------------------------------------------------------
 
 type WikiUpdateCall struct {
-	s         *Service
-	wikiPage  *WikiPage
-	projectId int
+	s        *Service
+	wikiPage *WikiPage
+	project  ProjectRef
 }
 
-func (r *WikiService) Update(wikiPage *WikiPage, projectId int) *WikiUpdateCall {
-	return &WikiUpdateCall{r.s, wikiPage, projectId}
+func (r *WikiService) Update(wikiPage *WikiPage, project ProjectRef) *WikiUpdateCall {
+	return &WikiUpdateCall{r.s, wikiPage, project}
 }
 
 func (c *WikiUpdateCall) Do() error {
@@ -2212,8 +3403,10 @@ func (c *WikiUpdateCall) Do() error {
 	if err != nil {
 		return err
 	}
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/{title}.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/{title}.json"), c.project)
+	if err != nil {
+		return err
+	}
 	urlStr = strings.Replace(urlStr, "{title}", c.wikiPage.Title, 1)
 	_, err = c.s.doRequest("PUT", urlStr, body)
 	return err
@@ -2224,20 +3417,22 @@ func (c *WikiUpdateCall) Do() error {
 //-------------------------------------------------------------------------
 
 type WikiDeleteCall struct {
-	s         *Service
-	title     string
-	projectId int
+	s       *Service
+	title   string
+	project ProjectRef
 }
 
-func (r *WikiService) Delete(title string, projectId int) *WikiDeleteCall {
-	return &WikiDeleteCall{r.s, title, projectId}
+func (r *WikiService) Delete(title string, project ProjectRef) *WikiDeleteCall {
+	return &WikiDeleteCall{r.s, title, project}
 }
 
 func (c *WikiDeleteCall) Do() error {
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/{title}.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/wiki/{title}.json"), c.project)
+	if err != nil {
+		return err
+	}
 	urlStr = strings.Replace(urlStr, "{title}", c.title, 1)
-	_, err := c.s.doRequest("DELETE", urlStr, nil)
+	_, err = c.s.doRequest("DELETE", urlStr, nil)
 	return err
 }
 
@@ -2317,10 +3512,23 @@ type Attachment struct {
 	ContentType string `json:"content_type"`
 	Filesize    int    `json:"filesize"`
 	ContentUrl  string `json:"content_url"`
+	Digest      string `json:"digest"`
 	Author      *Name  `json:"author"`
 	CreatedOn   string `json:"created_on"`
 }
 
+type attachment struct {
+	Filename    string `json:"filename,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (r *Attachment) toSend() *attachment {
+	newAttachment := new(attachment)
+	newAttachment.Filename = r.Filename
+	newAttachment.Description = r.Description
+	return newAttachment
+}
+
 //-------------------------------------------------------------------------
 // get attachment
 //-------------------------------------------------------------------------
@@ -2352,56 +3560,334 @@ func (c *AttachmentsGetCall) Do() (*Attachment, error) {
 }
 
 //-------------------------------------------------------------------------
-// issue statuses
-//-------------------------------------------------------------------------
-
-type IssueStatus struct {
-	Id        int    `json:"id"`
-	Name      string `json:"name"`
-	IsDefault bool   `json:"is_default"`
-	IsClosed  bool   `json:"is_closed"`
-}
-
-//-------------------------------------------------------------------------
-// list issue statuses
+// update attachment
 //-------------------------------------------------------------------------
 
-type IssueStatusesListCall struct {
-	s *Service
+type AttachmentsUpdateCall struct {
+	s          *Service
+	attachment *Attachment
 }
 
-func (r *IssueStatusesService) List() *IssueStatusesListCall {
-	return &IssueStatusesListCall{r.s}
+func (r *AttachmentsService) Update(attachment *Attachment) *AttachmentsUpdateCall {
+	return &AttachmentsUpdateCall{r.s, attachment}
 }
 
-func (c *IssueStatusesListCall) Do() ([]*IssueStatus, error) {
-	urlStr := resolveRelative(c.s.baseUrl, "issue_statuses.json")
-	data, err := c.s.doRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
+func (c *AttachmentsUpdateCall) Do() error {
+	v := struct {
+		Attachment *attachment `json:"attachment,omitempty"`
+	}{
+		c.attachment.toSend(),
 	}
-	ret := struct {
-		IssueStatuses []*IssueStatus `json:"issue_statuses"`
-	}{}
-	err = json.Unmarshal(data, &ret)
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return ret.IssueStatuses, nil
+	urlStr := resolveRelative(c.s.baseUrl, "attachments/{attachmentId}.json")
+	urlStr = strings.Replace(urlStr, "{attachmentId}", strconv.Itoa(c.attachment.Id), 1)
+	_, err = c.s.doRequest("PATCH", urlStr, body)
+	return err
 }
 
 //-------------------------------------------------------------------------
-// trackers
+// delete attachment
 //-------------------------------------------------------------------------
 
-type Tracker struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+type AttachmentsDeleteCall struct {
+	s            *Service
+	attachmentId int
+}
+
+func (r *AttachmentsService) Delete(attachmentId int) *AttachmentsDeleteCall {
+	return &AttachmentsDeleteCall{r.s, attachmentId}
+}
+
+func (c *AttachmentsDeleteCall) Do() error {
+	urlStr := resolveRelative(c.s.baseUrl, "attachments/{attachmentId}.json")
+	urlStr = strings.Replace(urlStr, "{attachmentId}", strconv.Itoa(c.attachmentId), 1)
+	_, err := c.s.doRequest("DELETE", urlStr, nil)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// prune attachments
+//-------------------------------------------------------------------------
+
+type AttachmentsPruneCall struct {
+	s          *Service
+	project    ProjectRef
+	olderThan  int
+	largerThan int
+	dryRun     bool
+}
+
+// Prune deletes the attachments of the issues of a project that are older
+// or bigger than the configured limits. An attachment matching either
+// limit is deleted.
+func (r *AttachmentsService) Prune(project ProjectRef) *AttachmentsPruneCall {
+	return &AttachmentsPruneCall{
+		s:       r.s,
+		project: project,
+	}
+}
+
+// OlderThan selects the attachments created more than days days ago.
+func (c *AttachmentsPruneCall) OlderThan(days int) *AttachmentsPruneCall {
+	c.olderThan = days
+	return c
+}
+
+// LargerThan selects the attachments bigger than megabytes MB.
+func (c *AttachmentsPruneCall) LargerThan(megabytes int) *AttachmentsPruneCall {
+	c.largerThan = megabytes
+	return c
+}
+
+// DryRun reports the matching attachments without deleting them.
+func (c *AttachmentsPruneCall) DryRun(dryRun bool) *AttachmentsPruneCall {
+	c.dryRun = dryRun
+	return c
+}
+
+// Do returns the attachments that were (or, in dry-run mode, would be)
+// deleted.
+func (c *AttachmentsPruneCall) Do() ([]*Attachment, error) {
+	if c.olderThan <= 0 && c.largerThan <= 0 {
+		return nil, errors.New("prune needs an age or size limit")
+	}
+	projectId, err := projectRef(c.project)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().AddDate(0, 0, -c.olderThan)
+	maxSize := c.largerThan * 1024 * 1024
+	var pruned []*Attachment
+	const limit = 100
+	for offset := 0; ; offset += limit {
+		feed, err := c.s.Issues.List().
+			Filter("project_id", projectId).
+			Filter("status_id", "*").
+			Attachments(true).
+			Offset(offset).
+			Limit(limit).
+			Do()
+		if err != nil {
+			return pruned, err
+		}
+		for _, issue := range feed.Issues {
+			for _, a := range issue.Attachments {
+				match := c.largerThan > 0 && a.Filesize > maxSize
+				if c.olderThan > 0 {
+					createdOn, err := time.Parse(time.RFC3339, a.CreatedOn)
+					if err == nil && createdOn.Before(cutoff) {
+						match = true
+					}
+				}
+				if !match {
+					continue
+				}
+				if !c.dryRun {
+					err = c.s.Attachments.Delete(a.Id).Do()
+					if err != nil {
+						return pruned, err
+					}
+				}
+				pruned = append(pruned, a)
+			}
+		}
+		if len(feed.Issues) == 0 || offset+limit >= feed.TotalCount {
+			break
+		}
+	}
+	return pruned, nil
 }
 
 //-------------------------------------------------------------------------
-// list trackers
-//-------------------------------------------------------------------------
+// download attachment
+//-------------------------------------------------------------------------
+
+type AttachmentsDownloadCall struct {
+	s            *Service
+	attachmentId int
+	offset       int64
+	verify       bool
+}
+
+func (r *AttachmentsService) Download(attachmentId int) *AttachmentsDownloadCall {
+	return &AttachmentsDownloadCall{
+		s:            r.s,
+		attachmentId: attachmentId,
+		verify:       true,
+	}
+}
+
+// Offset resumes the download at the given byte offset using an HTTP Range
+// request.
+func (c *AttachmentsDownloadCall) Offset(offset int64) *AttachmentsDownloadCall {
+	c.offset = offset
+	return c
+}
+
+// Verify controls whether the size and digest of the downloaded content are
+// checked against the attachment metadata. The digest can only be checked
+// when the whole file is downloaded.
+func (c *AttachmentsDownloadCall) Verify(verify bool) *AttachmentsDownloadCall {
+	c.verify = verify
+	return c
+}
+
+// Do returns the content of the attachment starting at the configured
+// offset. When verification is enabled, the final Read returns an error
+// instead of io.EOF if the content does not match the metadata.
+func (c *AttachmentsDownloadCall) Do() (io.ReadCloser, error) {
+	attachment, err := c.s.Attachments.Get(c.attachmentId).Do()
+	if err != nil {
+		return nil, err
+	}
+	urlStr := attachment.ContentUrl
+	if urlStr == "" {
+		urlStr = resolveRelative(c.s.baseUrl, "attachments/download/{attachmentId}")
+		urlStr = strings.Replace(urlStr, "{attachmentId}", strconv.Itoa(c.attachmentId), 1)
+	}
+	req, err := c.s.newRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", c.offset))
+	}
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		data, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, checkResponse(res.StatusCode, data)
+	}
+	if c.offset > 0 && res.StatusCode != http.StatusPartialContent {
+		// the server ignored the range, skip the part we already have
+		_, err = io.CopyN(ioutil.Discard, res.Body, c.offset)
+		if err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
+	if !c.verify {
+		return res.Body, nil
+	}
+	v := &verifyingReader{
+		rc:       res.Body,
+		expected: int64(attachment.Filesize) - c.offset,
+	}
+	if c.offset == 0 && attachment.Digest != "" {
+		v.digest = strings.ToLower(attachment.Digest)
+		switch len(v.digest) {
+		case md5.Size * 2:
+			v.hash = md5.New()
+		case sha256.Size * 2:
+			v.hash = sha256.New()
+		}
+	}
+	return v, nil
+}
+
+// WriteTo downloads the attachment into w.
+func (c *AttachmentsDownloadCall) WriteTo(w io.Writer) (int64, error) {
+	rc, err := c.Do()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return io.Copy(w, rc)
+}
+
+type verifyingReader struct {
+	rc       io.ReadCloser
+	n        int64
+	expected int64
+	hash     hash.Hash
+	digest   string
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.n += int64(n)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	if err != io.EOF {
+		return n, err
+	}
+	if r.expected > 0 && r.n != r.expected {
+		return n, fmt.Errorf("size mismatch: got %v bytes, want %v", r.n, r.expected)
+	}
+	if r.hash != nil {
+		if sum := hex.EncodeToString(r.hash.Sum(nil)); sum != r.digest {
+			return n, fmt.Errorf("digest mismatch: got %v, want %v", sum, r.digest)
+		}
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.rc.Close()
+}
+
+//-------------------------------------------------------------------------
+// issue statuses
+//-------------------------------------------------------------------------
+
+type IssueStatus struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+	IsClosed  bool   `json:"is_closed"`
+}
+
+//-------------------------------------------------------------------------
+// list issue statuses
+//-------------------------------------------------------------------------
+
+type IssueStatusesListCall struct {
+	s *Service
+}
+
+func (r *IssueStatusesService) List() *IssueStatusesListCall {
+	return &IssueStatusesListCall{r.s}
+}
+
+func (c *IssueStatusesListCall) Do() ([]*IssueStatus, error) {
+	urlStr := resolveRelative(c.s.baseUrl, "issue_statuses.json")
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		IssueStatuses []*IssueStatus `json:"issue_statuses"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.IssueStatuses, nil
+}
+
+//-------------------------------------------------------------------------
+// trackers
+//-------------------------------------------------------------------------
+
+type Tracker struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+//-------------------------------------------------------------------------
+// list trackers
+//-------------------------------------------------------------------------
 
 type TrackersListCall struct {
 	s *Service
@@ -2551,17 +4037,19 @@ func (r *IssueCategory) toSend() *issueCategory {
 //-------------------------------------------------------------------------
 
 type IssueCategoriesListCall struct {
-	s         *Service
-	projectId int
+	s       *Service
+	project ProjectRef
 }
 
-func (r *IssueCategoriesService) List(projectId int) *IssueCategoriesListCall {
-	return &IssueCategoriesListCall{r.s, projectId}
+func (r *IssueCategoriesService) List(project ProjectRef) *IssueCategoriesListCall {
+	return &IssueCategoriesListCall{r.s, project}
 }
 
 func (c *IssueCategoriesListCall) Do() ([]*IssueCategory, error) {
-	urlStr := resolveRelative(c.s.baseUrl, "projects/{projectId}/issue_categories.json")
-	urlStr = strings.Replace(urlStr, "{projectId}", strconv.Itoa(c.projectId), 1)
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/issue_categories.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
 	data, err := c.s.doRequest("GET", urlStr, nil)
 	if err != nil {
 		return nil, err
@@ -2815,10 +4303,7 @@ func (r *Group) toSend() *group {
 	if r.CustomFields != nil {
 		newGroup.CustomFields = make([]*customField, len(r.CustomFields))
 		for i, cf := range r.CustomFields {
-			newGroup.CustomFields[i] = &customField{
-				Id:    cf.Id,
-				Value: cf.Value,
-			}
+			newGroup.CustomFields[i] = cf.toSend()
 		}
 	}
 	return newGroup
@@ -3053,3 +4538,437 @@ func (c *GroupsRemoveUserCall) Do() error {
 	_, err := c.s.doRequest("DELETE", urlStr, nil)
 	return err
 }
+
+//-------------------------------------------------------------------------
+// files
+//-------------------------------------------------------------------------
+
+type File struct {
+	Id          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Filesize    int    `json:"filesize"`
+	ContentType string `json:"content_type"`
+	Description string `json:"description"`
+	ContentUrl  string `json:"content_url"`
+	Author      *Name  `json:"author"`
+	Version     *Name  `json:"version"`
+	Digest      string `json:"digest"`
+	Downloads   int    `json:"downloads"`
+	CreatedOn   string `json:"created_on"`
+}
+
+type file struct {
+	Token       string `json:"token,omitempty"`
+	VersionId   int    `json:"version_id,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (r *File) toSend(upload *Upload) *file {
+	newFile := new(file)
+	if upload != nil {
+		newFile.Token = upload.Token
+		newFile.Filename = upload.Filename
+		newFile.Description = upload.Description
+	}
+	if r.Version != nil {
+		newFile.VersionId = r.Version.Id
+	}
+	if r.Filename != "" {
+		newFile.Filename = r.Filename
+	}
+	if r.Description != "" {
+		newFile.Description = r.Description
+	}
+	return newFile
+}
+
+//-------------------------------------------------------------------------
+// list files
+//-------------------------------------------------------------------------
+
+type FilesListCall struct {
+	s       *Service
+	project ProjectRef
+}
+
+func (r *FilesService) List(project ProjectRef) *FilesListCall {
+	return &FilesListCall{r.s, project}
+}
+
+func (c *FilesListCall) Do() ([]*File, error) {
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/files.json"), c.project)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		Files []*File `json:"files"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.Files, nil
+}
+
+//-------------------------------------------------------------------------
+// insert file
+//-------------------------------------------------------------------------
+
+type FilesInsertCall struct {
+	s       *Service
+	project ProjectRef
+	file    *File
+	upload  *Upload
+}
+
+// Insert publishes an uploaded file in the files section of a project. Set
+// file.Version to bind it to a version; its Filename and Description
+// override the ones of the upload when set.
+func (r *FilesService) Insert(project ProjectRef, file *File, upload *Upload) *FilesInsertCall {
+	return &FilesInsertCall{r.s, project, file, upload}
+}
+
+func (c *FilesInsertCall) Do() error {
+	v := struct {
+		File *file `json:"file,omitempty"`
+	}{
+		c.file.toSend(c.upload),
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr, err := expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/files.json"), c.project)
+	if err != nil {
+		return err
+	}
+	_, err = c.s.doRequest("POST", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// search
+//-------------------------------------------------------------------------
+
+type SearchFeed struct {
+	Results    []*SearchResult `json:"results"`
+	TotalCount int             `json:"total_count"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+}
+
+type SearchResult struct {
+	Id          int    `json:"id"`
+	Title       string `json:"title"`
+	Type        string `json:"type"`
+	Url         string `json:"url"`
+	Description string `json:"description"`
+	Datetime    string `json:"datetime"`
+}
+
+//-------------------------------------------------------------------------
+// run search
+//-------------------------------------------------------------------------
+
+type SearchSearchCall struct {
+	s         *Service
+	query     string
+	project   ProjectRef
+	resources []string
+	options   map[string]interface{}
+}
+
+func (r *SearchService) Search(query string) *SearchSearchCall {
+	return &SearchSearchCall{
+		s:       r.s,
+		query:   query,
+		options: make(map[string]interface{}),
+	}
+}
+
+func (c *SearchSearchCall) Offset(offset int) *SearchSearchCall {
+	c.options["offset"] = offset
+	return c
+}
+
+func (c *SearchSearchCall) Limit(limit int) *SearchSearchCall {
+	c.options["limit"] = limit
+	return c
+}
+
+// Project restricts the search to a project.
+func (c *SearchSearchCall) Project(project ProjectRef) *SearchSearchCall {
+	c.project = project
+	return c
+}
+
+// Scope is one of "all", "my_projects" or "subprojects".
+func (c *SearchSearchCall) Scope(scope string) *SearchSearchCall {
+	c.options["scope"] = scope
+	return c
+}
+
+// Resources restricts the search to the given resource types: issues,
+// wiki_pages, news, changesets, documents, messages and projects.
+func (c *SearchSearchCall) Resources(resources ...string) *SearchSearchCall {
+	c.resources = resources
+	return c
+}
+
+func (c *SearchSearchCall) TitlesOnly(titlesOnly bool) *SearchSearchCall {
+	c.options["titles_only"] = titlesOnly
+	return c
+}
+
+func (c *SearchSearchCall) OpenIssues(openIssues bool) *SearchSearchCall {
+	c.options["open_issues"] = openIssues
+	return c
+}
+
+func (c *SearchSearchCall) Do() (*SearchFeed, error) {
+	params := make(url.Values)
+	params.Set("q", c.query)
+	for _, opt := range []string{"offset", "limit", "scope"} {
+		if v, ok := c.options[opt]; ok {
+			params.Set(opt, fmt.Sprintf("%v", v))
+		}
+	}
+	for _, opt := range []string{"titles_only", "open_issues"} {
+		if b, ok := c.options[opt].(bool); ok && b {
+			params.Set(opt, "1")
+		}
+	}
+	for _, res := range c.resources {
+		params.Set(res, "1")
+	}
+	var urlStr string
+	var err error
+	if c.project == nil {
+		urlStr = resolveRelative(c.s.baseUrl, "search.json")
+	} else {
+		urlStr, err = expandProject(resolveRelative(c.s.baseUrl, "projects/{projectId}/search.json"), c.project)
+		if err != nil {
+			return nil, err
+		}
+	}
+	urlStr += "?" + params.Encode()
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := new(SearchFeed)
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Pages calls f for each page of results, starting at the configured offset.
+// Iteration stops when f returns an error, which is then returned.
+func (c *SearchSearchCall) Pages(f func(*SearchFeed) error) error {
+	offset, _ := c.options["offset"].(int)
+	for {
+		c.options["offset"] = offset
+		feed, err := c.Do()
+		if err != nil {
+			return err
+		}
+		err = f(feed)
+		if err != nil {
+			return err
+		}
+		offset += len(feed.Results)
+		if len(feed.Results) == 0 || offset >= feed.TotalCount {
+			return nil
+		}
+	}
+}
+
+//-------------------------------------------------------------------------
+// my account
+//-------------------------------------------------------------------------
+
+// note: only the name, mail, custom fields and preferences can be updated
+
+type myAccount struct {
+	Firstname    string         `json:"firstname,omitempty"`
+	Lastname     string         `json:"lastname,omitempty"`
+	Mail         string         `json:"mail,omitempty"`
+	CustomFields []*customField `json:"custom_fields,omitempty"`
+}
+
+//-------------------------------------------------------------------------
+// get my account
+//-------------------------------------------------------------------------
+
+type MyAccountGetCall struct {
+	s *Service
+}
+
+func (r *MyAccountService) Get() *MyAccountGetCall {
+	return &MyAccountGetCall{r.s}
+}
+
+func (c *MyAccountGetCall) Do() (*User, error) {
+	urlStr := resolveRelative(c.s.baseUrl, "my/account.json")
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		User *User `json:"user"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.User, nil
+}
+
+//-------------------------------------------------------------------------
+// update my account
+//-------------------------------------------------------------------------
+
+type MyAccountUpdateCall struct {
+	s    *Service
+	user *User
+}
+
+func (r *MyAccountService) Update(user *User) *MyAccountUpdateCall {
+	return &MyAccountUpdateCall{r.s, user}
+}
+
+func (c *MyAccountUpdateCall) Do() error {
+	newUser := c.user.toSend()
+	v := struct {
+		User *myAccount       `json:"user,omitempty"`
+		Pref *UserPreferences `json:"pref,omitempty"`
+	}{
+		&myAccount{
+			Firstname:    newUser.Firstname,
+			Lastname:     newUser.Lastname,
+			Mail:         newUser.Mail,
+			CustomFields: newUser.CustomFields,
+		},
+		c.user.Pref,
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "my/account.json")
+	_, err = c.s.doRequest("PUT", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// journals
+//-------------------------------------------------------------------------
+
+type journal struct {
+	Notes        string `json:"notes"`
+	PrivateNotes bool   `json:"private_notes"`
+}
+
+func (r *IssueJournal) toSend() *journal {
+	newJournal := new(journal)
+	newJournal.Notes = r.Notes
+	newJournal.PrivateNotes = r.PrivateNotes
+	return newJournal
+}
+
+//-------------------------------------------------------------------------
+// update journal
+//-------------------------------------------------------------------------
+
+// note: a journal without details is deleted when its notes are cleared
+
+type JournalsUpdateCall struct {
+	s       *Service
+	journal *IssueJournal
+}
+
+func (r *JournalsService) Update(journal *IssueJournal) *JournalsUpdateCall {
+	return &JournalsUpdateCall{r.s, journal}
+}
+
+func (c *JournalsUpdateCall) Do() error {
+	v := struct {
+		Journal *journal `json:"journal,omitempty"`
+	}{
+		c.journal.toSend(),
+	}
+	body := new(bytes.Buffer)
+	err := json.NewEncoder(body).Encode(&v)
+	if err != nil {
+		return err
+	}
+	urlStr := resolveRelative(c.s.baseUrl, "journals/{journalId}.json")
+	urlStr = strings.Replace(urlStr, "{journalId}", strconv.Itoa(c.journal.Id), 1)
+	_, err = c.s.doRequest("PUT", urlStr, body)
+	return err
+}
+
+//-------------------------------------------------------------------------
+// custom field definitions
+//-------------------------------------------------------------------------
+
+type CustomFieldDefinition struct {
+	Id             int                    `json:"id"`
+	Name           string                 `json:"name"`
+	CustomizedType string                 `json:"customized_type"`
+	FieldFormat    string                 `json:"field_format"`
+	Regexp         string                 `json:"regexp"`
+	MinLength      Nint                   `json:"min_length"`
+	MaxLength      Nint                   `json:"max_length"`
+	IsRequired     bool                   `json:"is_required"`
+	IsFilter       bool                   `json:"is_filter"`
+	Searchable     bool                   `json:"searchable"`
+	Multiple       bool                   `json:"multiple"`
+	DefaultValue   string                 `json:"default_value"`
+	Visible        bool                   `json:"visible"`
+	PossibleValues []*CustomFieldPossible `json:"possible_values"`
+	Trackers       []*Name                `json:"trackers"`
+	Roles          []*Name                `json:"roles"`
+}
+
+type CustomFieldPossible struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+//-------------------------------------------------------------------------
+// list custom field definitions
+//-------------------------------------------------------------------------
+
+type CustomFieldsListCall struct {
+	s *Service
+}
+
+func (r *CustomFieldsService) List() *CustomFieldsListCall {
+	return &CustomFieldsListCall{r.s}
+}
+
+func (c *CustomFieldsListCall) Do() ([]*CustomFieldDefinition, error) {
+	urlStr := resolveRelative(c.s.baseUrl, "custom_fields.json")
+	data, err := c.s.doRequest("GET", urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := struct {
+		CustomFields []*CustomFieldDefinition `json:"custom_fields"`
+	}{}
+	err = json.Unmarshal(data, &ret)
+	if err != nil {
+		return nil, err
+	}
+	return ret.CustomFields, nil
+}
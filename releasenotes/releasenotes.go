@@ -0,0 +1,186 @@
+// Package releasenotes generates release notes from the issues closed in a
+// Redmine version and publishes them as a wiki page or news.
+package releasenotes
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strconv"
+	"text/template"
+
+	"github.com/woli/redmine"
+)
+
+type GroupBy int
+
+const (
+	ByTracker GroupBy = iota
+	ByCategory
+)
+
+type Group struct {
+	Name   string
+	Issues []*redmine.Issue
+}
+
+type Notes struct {
+	Version *redmine.Version
+	Groups  []*Group
+	Issues  []*redmine.Issue
+}
+
+// Generate lists the closed issues of a version and groups them by tracker
+// or category. Groups are sorted by name, issues by id; issues without a
+// tracker or category go to an "Other" group.
+func Generate(s *redmine.Service, versionId int, groupBy GroupBy) (*Notes, error) {
+	version, err := s.Versions.Get(versionId).Do()
+	if err != nil {
+		return nil, err
+	}
+	n := &Notes{Version: version}
+	err = s.Issues.List().
+		Filter("fixed_version_id", strconv.Itoa(versionId)).
+		Filter("status_id", "closed").
+		Sort("id").
+		Pages(func(feed *redmine.IssueFeed) error {
+			n.Issues = append(n.Issues, feed.Issues...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*Group)
+	for _, issue := range n.Issues {
+		name := "Other"
+		switch groupBy {
+		case ByTracker:
+			if issue.Tracker != nil {
+				name = issue.Tracker.Name
+			}
+		case ByCategory:
+			if issue.Category != nil {
+				name = issue.Category.Name
+			}
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &Group{Name: name}
+			groups[name] = g
+			n.Groups = append(n.Groups, g)
+		}
+		g.Issues = append(g.Issues, issue)
+	}
+	sort.Slice(n.Groups, func(i, j int) bool {
+		return n.Groups[i].Name < n.Groups[j].Name
+	})
+	return n, nil
+}
+
+//-------------------------------------------------------------------------
+// templates
+//-------------------------------------------------------------------------
+
+// Template is satisfied by both text/template and html/template templates.
+// They are executed with the Notes as data.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+var Markdown = template.Must(template.New("markdown").Parse(
+	`# {{.Version.Name}}
+{{with .Version.DueDate}}
+Released on {{.}}
+{{end}}{{with .Version.Description}}
+{{.}}
+{{end}}{{range .Groups}}
+## {{.Name}}
+
+{{range .Issues}}* #{{.Id}} {{.Subject}}
+{{end}}{{end}}`))
+
+var Textile = template.Must(template.New("textile").Parse(
+	`h1. {{.Version.Name}}
+{{with .Version.DueDate}}
+Released on {{.}}
+{{end}}{{with .Version.Description}}
+{{.}}
+{{end}}{{range .Groups}}
+h2. {{.Name}}
+
+{{range .Issues}}* #{{.Id}} {{.Subject}}
+{{end}}{{end}}`))
+
+var HTML = htmltemplate.Must(htmltemplate.New("html").Parse(
+	`<h1>{{.Version.Name}}</h1>
+{{with .Version.DueDate}}<p>Released on {{.}}</p>
+{{end}}{{with .Version.Description}}<p>{{.}}</p>
+{{end}}{{range .Groups}}<h2>{{.Name}}</h2>
+<ul>
+{{range .Issues}}<li>#{{.Id}} {{.Subject}}</li>
+{{end}}</ul>
+{{end}}`))
+
+func (n *Notes) Render(w io.Writer, tmpl Template) error {
+	return tmpl.Execute(w, n)
+}
+
+func (n *Notes) String(tmpl Template) (string, error) {
+	b := new(bytes.Buffer)
+	err := n.Render(b, tmpl)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+//-------------------------------------------------------------------------
+// publish
+//-------------------------------------------------------------------------
+
+func (n *Notes) project() (int, error) {
+	if n.Version.Project == nil {
+		return 0, errors.New("version has no project")
+	}
+	return n.Version.Project.Id, nil
+}
+
+// PublishWiki creates or updates a wiki page of the project of the version
+// with the notes rendered by tmpl.
+func (n *Notes) PublishWiki(s *redmine.Service, title string, tmpl Template) error {
+	projectId, err := n.project()
+	if err != nil {
+		return err
+	}
+	text, err := n.String(tmpl)
+	if err != nil {
+		return err
+	}
+	page := &redmine.WikiPage{
+		Title:    title,
+		Text:     text,
+		Comments: "Release notes of " + n.Version.Name,
+	}
+	return s.Wiki.Update(page, redmine.ProjectId(projectId)).Do()
+}
+
+// PublishNews posts the notes rendered by tmpl as news of the project of
+// the version.
+func (n *Notes) PublishNews(s *redmine.Service, title, summary string, tmpl Template) error {
+	projectId, err := n.project()
+	if err != nil {
+		return err
+	}
+	text, err := n.String(tmpl)
+	if err != nil {
+		return err
+	}
+	news := &redmine.News{
+		Title:       title,
+		Summary:     summary,
+		Description: text,
+	}
+	return s.News.Insert(news, redmine.ProjectId(projectId)).Do()
+}
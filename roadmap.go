@@ -0,0 +1,160 @@
+package redmine
+
+import (
+	"strconv"
+	"time"
+)
+
+//-------------------------------------------------------------------------
+// roadmap
+//-------------------------------------------------------------------------
+
+// RoadmapVersion is a version with its fixed issues and progress, computed
+// the way the roadmap page of redmine does.
+type RoadmapVersion struct {
+	Version *Version
+	Issues  []*Issue
+	Total   int
+	Open    int
+	Closed  int
+	// EstimatedHours sums the estimates of the leaf issues, SpentHours the
+	// hours spent on all the issues.
+	EstimatedHours float64
+	SpentHours     float64
+	// ClosedPercent is the share of closed issues, CompletedPercent the
+	// progress of the issues weighted by their estimates, closed issues
+	// counting as done.
+	ClosedPercent    float64
+	CompletedPercent float64
+	// Overdue tells whether the due date has passed with issues still open.
+	Overdue bool
+}
+
+// Completed tells whether the version is closed, or past its due date
+// without open issues.
+func (r *RoadmapVersion) Completed() bool {
+	if r.Version.Status == "closed" {
+		return true
+	}
+	due, err := time.Parse(DateLayout, r.Version.DueDate)
+	return err == nil && due.Before(today()) && r.Open == 0
+}
+
+type VersionsRoadmapCall struct {
+	s         *Service
+	project   ProjectRef
+	completed bool
+}
+
+// Roadmap lists the versions available to a project, including the ones
+// shared by other projects, with their progress.
+func (r *VersionsService) Roadmap(project ProjectRef) *VersionsRoadmapCall {
+	return &VersionsRoadmapCall{
+		s:       r.s,
+		project: project,
+	}
+}
+
+// Completed includes the completed versions, which are left out by
+// default.
+func (c *VersionsRoadmapCall) Completed(completed bool) *VersionsRoadmapCall {
+	c.completed = completed
+	return c
+}
+
+func (c *VersionsRoadmapCall) Do() ([]*RoadmapVersion, error) {
+	statuses, err := c.s.IssueStatuses.List().Do()
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[int]bool)
+	for _, status := range statuses {
+		closed[status.Id] = status.IsClosed
+	}
+	versions, err := c.s.Versions.List(c.project).Do()
+	if err != nil {
+		return nil, err
+	}
+	var roadmap []*RoadmapVersion
+	for _, version := range versions {
+		issues, err := c.s.Issues.listAll(map[string]string{
+			"fixed_version_id": strconv.Itoa(version.Id),
+			"status_id":        "*",
+		})
+		if err != nil {
+			return nil, err
+		}
+		rv := newRoadmapVersion(version, issues, closed)
+		if !c.completed && rv.Completed() {
+			continue
+		}
+		roadmap = append(roadmap, rv)
+	}
+	return roadmap, nil
+}
+
+func newRoadmapVersion(version *Version, issues []*Issue, closed map[int]bool) *RoadmapVersion {
+	rv := &RoadmapVersion{
+		Version: version,
+		Issues:  issues,
+		Total:   len(issues),
+	}
+	parents := make(map[int]bool)
+	for _, issue := range issues {
+		if issue.Parent != nil {
+			parents[issue.Parent.Id] = true
+		}
+	}
+	isClosed := func(issue *Issue) bool {
+		return issue.Status != nil && closed[issue.Status.Id]
+	}
+	estimated, count := 0.0, 0
+	for _, issue := range issues {
+		if isClosed(issue) {
+			rv.Closed++
+		} else {
+			rv.Open++
+		}
+		if !parents[issue.Id] {
+			rv.EstimatedHours += issue.EstimatedHours
+		}
+		rv.SpentHours += issue.SpentHours
+		if issue.EstimatedHours > 0 {
+			estimated += issue.EstimatedHours
+			count++
+		}
+	}
+	if rv.Total == 0 {
+		return rv
+	}
+	rv.ClosedPercent = float64(rv.Closed) * 100 / float64(rv.Total)
+	if rv.Open == 0 {
+		rv.CompletedPercent = 100
+	} else {
+		average := 1.0
+		if count > 0 {
+			average = estimated / float64(count)
+		}
+		done := 0.0
+		for _, issue := range issues {
+			weight := issue.EstimatedHours
+			if weight <= 0 {
+				weight = average
+			}
+			ratio := float64(issue.DoneRatio)
+			if isClosed(issue) {
+				ratio = 100
+			}
+			done += weight * ratio
+		}
+		rv.CompletedPercent = done / (average * float64(rv.Total))
+	}
+	due, err := time.Parse(DateLayout, version.DueDate)
+	rv.Overdue = err == nil && due.Before(today()) && rv.Open > 0
+	return rv
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,130 @@
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//-------------------------------------------------------------------------
+// icalendar
+//-------------------------------------------------------------------------
+
+type ICalOptions struct {
+	// Events writes the issues as VEVENTs spanning their dates instead of
+	// VTODOs. Milestones are always VEVENTs.
+	Events bool
+	// Domain is the right-hand side of the UIDs, "redmine" by default.
+	Domain string
+	// BaseUrl, when set, adds the redmine URL of each issue and version.
+	BaseUrl string
+}
+
+const icalDateLayout = "20060102"
+
+// WriteICal renders the schedule as an iCalendar feed.
+func (sc *Schedule) WriteICal(w io.Writer, opts *ICalOptions) error {
+	if opts == nil {
+		opts = new(ICalOptions)
+	}
+	domain := opts.Domain
+	if domain == "" {
+		domain = "redmine"
+	}
+	baseUrl := strings.TrimSuffix(opts.BaseUrl, "/")
+	stamp := sc.Generated.UTC().Format("20060102T150405Z")
+	b := &icalWriter{w: bufio.NewWriter(w)}
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//woli//redmine//EN")
+	b.line("CALSCALE:GREGORIAN")
+	if sc.Title != "" {
+		b.line("X-WR-CALNAME:" + icalText(sc.Title))
+	}
+	for _, task := range sc.Tasks {
+		issue := task.Issue
+		component := "VTODO"
+		if opts.Events {
+			component = "VEVENT"
+		}
+		b.line("BEGIN:" + component)
+		b.line(fmt.Sprintf("UID:issue-%v@%v", issue.Id, domain))
+		b.line("DTSTAMP:" + stamp)
+		b.line("DTSTART;VALUE=DATE:" + task.Start.Format(icalDateLayout))
+		if opts.Events {
+			// the end of an all-day event is exclusive
+			b.line("DTEND;VALUE=DATE:" + task.Due.AddDate(0, 0, 1).Format(icalDateLayout))
+		} else {
+			b.line("DUE;VALUE=DATE:" + task.Due.Format(icalDateLayout))
+			b.line(fmt.Sprintf("PERCENT-COMPLETE:%v", issue.DoneRatio))
+			if task.Closed {
+				b.line("STATUS:COMPLETED")
+			} else {
+				b.line("STATUS:NEEDS-ACTION")
+			}
+		}
+		b.line("SUMMARY:" + icalText(fmt.Sprintf("#%v %v", issue.Id, issue.Subject)))
+		if issue.Description != "" {
+			b.line("DESCRIPTION:" + icalText(issue.Description))
+		}
+		if baseUrl != "" {
+			b.line(fmt.Sprintf("URL:%v/issues/%v", baseUrl, issue.Id))
+		}
+		b.line("END:" + component)
+	}
+	for _, m := range sc.Milestones {
+		b.line("BEGIN:VEVENT")
+		b.line(fmt.Sprintf("UID:version-%v@%v", m.Version.Id, domain))
+		b.line("DTSTAMP:" + stamp)
+		b.line("DTSTART;VALUE=DATE:" + m.Due.Format(icalDateLayout))
+		b.line("DTEND;VALUE=DATE:" + m.Due.AddDate(0, 0, 1).Format(icalDateLayout))
+		b.line("SUMMARY:" + icalText(m.Version.Name))
+		if m.Version.Description != "" {
+			b.line("DESCRIPTION:" + icalText(m.Version.Description))
+		}
+		if baseUrl != "" {
+			b.line(fmt.Sprintf("URL:%v/versions/%v", baseUrl, m.Version.Id))
+		}
+		b.line("END:VEVENT")
+	}
+	b.line("END:VCALENDAR")
+	return b.flush()
+}
+
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalWriter writes CRLF terminated content lines folded at 75 octets.
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (b *icalWriter) line(s string) {
+	// continuation lines start with a space
+	for width := 75; len(s) > width; width = 74 {
+		n := width
+		// do not split a multi-byte character
+		for n > 0 && s[n]&0xc0 == 0x80 {
+			n--
+		}
+		b.write(s[:n] + "\r\n ")
+		s = s[n:]
+	}
+	b.write(s + "\r\n")
+}
+
+func (b *icalWriter) write(s string) {
+	if b.err == nil {
+		_, b.err = b.w.WriteString(s)
+	}
+}
+
+func (b *icalWriter) flush() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.w.Flush()
+}
@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/woli/redmine"
+)
+
+//-------------------------------------------------------------------------
+// mermaid gantt
+//-------------------------------------------------------------------------
+
+// WriteMermaid renders the schedule as a Mermaid gantt chart with one
+// section per version.
+func (sc *Schedule) WriteMermaid(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "gantt")
+	if sc.Title != "" {
+		fmt.Fprintf(b, "    title %v\n", mermaidText(sc.Title))
+	}
+	fmt.Fprintln(b, "    dateFormat YYYY-MM-DD")
+	var sections []string
+	tasks := make(map[string][]*Task)
+	for _, task := range sc.Tasks {
+		section := "No version"
+		if task.Issue.FixedVersion != nil {
+			section = task.Issue.FixedVersion.Name
+		}
+		if _, ok := tasks[section]; !ok {
+			sections = append(sections, section)
+		}
+		tasks[section] = append(tasks[section], task)
+	}
+	for _, section := range sections {
+		fmt.Fprintf(b, "    section %v\n", mermaidText(section))
+		for _, task := range tasks[section] {
+			tags := ""
+			if task.Closed {
+				tags = "done, "
+			}
+			fmt.Fprintf(b, "    %v :%vi%v, %v, %vd\n",
+				mermaidText(task.Issue.Subject), tags, task.Issue.Id,
+				task.Start.Format(redmine.DateLayout), task.Days())
+		}
+	}
+	if len(sc.Milestones) > 0 {
+		fmt.Fprintln(b, "    section Milestones")
+		for _, m := range sc.Milestones {
+			fmt.Fprintf(b, "    %v :milestone, v%v, %v, 0d\n",
+				mermaidText(m.Version.Name), m.Version.Id, m.Due.Format(redmine.DateLayout))
+		}
+	}
+	return b.Flush()
+}
+
+// mermaidText removes the characters that end a gantt task name or line.
+func mermaidText(s string) string {
+	return strings.NewReplacer(":", " ", ";", " ", "\r", " ", "\n", " ", "#", "").Replace(s)
+}
@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+//-------------------------------------------------------------------------
+// ms project xml
+//-------------------------------------------------------------------------
+
+const msProjectDateLayout = "2006-01-02T15:04:05"
+
+// lag and duration units of ms project: tenths of a minute, 8 hours a day
+const msProjectTenthsPerDay = 8 * 60 * 10
+
+type msProject struct {
+	XMLName xml.Name        `xml:"http://schemas.microsoft.com/project Project"`
+	Name    string          `xml:"Name"`
+	Title   string          `xml:"Title"`
+	Created string          `xml:"CreationDate"`
+	Tasks   []msProjectTask `xml:"Tasks>Task"`
+}
+
+type msProjectTask struct {
+	UID             int                 `xml:"UID"`
+	ID              int                 `xml:"ID"`
+	Name            string              `xml:"Name"`
+	Start           string              `xml:"Start"`
+	Finish          string              `xml:"Finish"`
+	Duration        string              `xml:"Duration"`
+	Milestone       int                 `xml:"Milestone"`
+	PercentComplete int                 `xml:"PercentComplete"`
+	OutlineLevel    int                 `xml:"OutlineLevel"`
+	Notes           string              `xml:"Notes,omitempty"`
+	Predecessors    []msProjectPredLink `xml:"PredecessorLink"`
+}
+
+type msProjectPredLink struct {
+	PredecessorUID int `xml:"PredecessorUID"`
+	// Type 1 is finish-to-start
+	Type      int `xml:"Type"`
+	LinkLag   int `xml:"LinkLag"`
+	LagFormat int `xml:"LagFormat"`
+}
+
+// WriteMSProject renders the schedule as MS Project XML. The precedes and
+// blocks relations between the tasks become finish-to-start links, and
+// the version due dates become milestones.
+func (sc *Schedule) WriteMSProject(w io.Writer) error {
+	p := &msProject{
+		Name:    sc.Title,
+		Title:   sc.Title,
+		Created: sc.Generated.Format(msProjectDateLayout),
+	}
+	uids := make(map[int]int)
+	for i, task := range sc.Tasks {
+		uids[task.Issue.Id] = i + 1
+	}
+	for i, task := range sc.Tasks {
+		t := msProjectTask{
+			UID:             i + 1,
+			ID:              i + 1,
+			Name:            fmt.Sprintf("#%v %v", task.Issue.Id, task.Issue.Subject),
+			Start:           workStart(task.Start),
+			Finish:          workFinish(task.Due),
+			Duration:        fmt.Sprintf("PT%dH0M0S", task.Days()*8),
+			PercentComplete: task.Issue.DoneRatio,
+			OutlineLevel:    1,
+			Notes:           task.Issue.Description,
+		}
+		if task.Closed {
+			t.PercentComplete = 100
+		}
+		for _, rel := range task.Issue.Relations {
+			from, to := rel.IssueId, rel.IssueToId
+			switch rel.RelationType {
+			case "blocks", "precedes":
+			case "blocked", "follows":
+				from, to = to, from
+			default:
+				continue
+			}
+			if to != task.Issue.Id {
+				continue
+			}
+			uid, ok := uids[from]
+			if !ok {
+				continue
+			}
+			link := msProjectPredLink{PredecessorUID: uid, Type: 1, LagFormat: 7}
+			if rel.RelationType == "precedes" || rel.RelationType == "follows" {
+				link.LinkLag = int(rel.Delay) * msProjectTenthsPerDay
+			}
+			t.Predecessors = append(t.Predecessors, link)
+		}
+		p.Tasks = append(p.Tasks, t)
+	}
+	for i, m := range sc.Milestones {
+		uid := len(sc.Tasks) + i + 1
+		p.Tasks = append(p.Tasks, msProjectTask{
+			UID:          uid,
+			ID:           uid,
+			Name:         m.Version.Name,
+			Start:        workFinish(m.Due),
+			Finish:       workFinish(m.Due),
+			Duration:     "PT0H0M0S",
+			Milestone:    1,
+			OutlineLevel: 1,
+			Notes:        m.Version.Description,
+		})
+	}
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(p)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func workStart(day time.Time) string {
+	return day.Add(8 * time.Hour).Format(msProjectDateLayout)
+}
+
+func workFinish(day time.Time) string {
+	return day.Add(17 * time.Hour).Format(msProjectDateLayout)
+}
@@ -0,0 +1,144 @@
+// Package schedule exports the dated issues and version milestones of a
+// Redmine project or version as Mermaid gantt charts, MS Project XML and
+// iCalendar feeds.
+package schedule
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/woli/redmine"
+)
+
+// Task is an issue with a start or due date. When only one of the dates is
+// set, the other one takes its value.
+type Task struct {
+	Issue  *redmine.Issue
+	Start  time.Time
+	Due    time.Time
+	Closed bool
+}
+
+// Days returns the number of days of the task, counting both ends.
+func (t *Task) Days() int {
+	return int(t.Due.Sub(t.Start).Hours()/24) + 1
+}
+
+// Milestone is a version with a due date.
+type Milestone struct {
+	Version *redmine.Version
+	Due     time.Time
+}
+
+type Schedule struct {
+	Title      string
+	Tasks      []*Task
+	Milestones []*Milestone
+	// Generated is the time the schedule was loaded.
+	Generated time.Time
+}
+
+type Scope struct {
+	// Project and VersionId select the issues; both may be set.
+	Project   redmine.ProjectRef
+	VersionId int
+	// OpenOnly leaves the closed issues out.
+	OpenOnly bool
+}
+
+// Load lists the issues of the scope, and the versions of the project (or
+// the version itself) as milestones. Issues without dates are left out.
+// The relations of the issues are loaded too, as WriteMSProject turns them
+// into predecessor links. Tasks are sorted by start date, then id.
+func Load(s *redmine.Service, scope Scope) (*Schedule, error) {
+	if scope.Project == nil && scope.VersionId == 0 {
+		return nil, errors.New("scope needs a project or a version")
+	}
+	statuses, err := s.IssueStatuses.List().Do()
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[int]bool)
+	for _, status := range statuses {
+		closed[status.Id] = status.IsClosed
+	}
+	sc := &Schedule{Generated: time.Now()}
+	var versions []*redmine.Version
+	if scope.VersionId > 0 {
+		version, err := s.Versions.Get(scope.VersionId).Do()
+		if err != nil {
+			return nil, err
+		}
+		versions = []*redmine.Version{version}
+		sc.Title = version.Name
+	} else {
+		versions, err = s.Versions.List(scope.Project).Do()
+		if err != nil {
+			return nil, err
+		}
+		sc.Title = scope.Project.String()
+	}
+	for _, version := range versions {
+		due, err := time.Parse(redmine.DateLayout, version.DueDate)
+		if err != nil {
+			continue
+		}
+		sc.Milestones = append(sc.Milestones, &Milestone{version, due})
+	}
+	call := s.Issues.List().Relations(true).Sort("start_date,id")
+	if scope.Project != nil {
+		call.Filter("project_id", scope.Project.String())
+	}
+	if scope.VersionId > 0 {
+		call.Filter("fixed_version_id", strconv.Itoa(scope.VersionId))
+	}
+	if scope.OpenOnly {
+		call.Filter("status_id", "open")
+	} else {
+		call.Filter("status_id", "*")
+	}
+	err = call.Pages(func(feed *redmine.IssueFeed) error {
+		for _, issue := range feed.Issues {
+			task := newTask(issue)
+			if task == nil {
+				continue
+			}
+			task.Closed = issue.Status != nil && closed[issue.Status.Id]
+			sc.Tasks = append(sc.Tasks, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sc.Tasks, func(i, j int) bool {
+		a, b := sc.Tasks[i], sc.Tasks[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.Issue.Id < b.Issue.Id
+	})
+	sort.SliceStable(sc.Milestones, func(i, j int) bool {
+		return sc.Milestones[i].Due.Before(sc.Milestones[j].Due)
+	})
+	return sc, nil
+}
+
+func newTask(issue *redmine.Issue) *Task {
+	start, err1 := time.Parse(redmine.DateLayout, issue.StartDate)
+	due, err2 := time.Parse(redmine.DateLayout, issue.DueDate)
+	switch {
+	case err1 != nil && err2 != nil:
+		return nil
+	case err1 != nil:
+		start = due
+	case err2 != nil:
+		due = start
+	}
+	if due.Before(start) {
+		due = start
+	}
+	return &Task{Issue: issue, Start: start, Due: due}
+}
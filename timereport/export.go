@@ -0,0 +1,68 @@
+package timereport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+//-------------------------------------------------------------------------
+// export
+//-------------------------------------------------------------------------
+
+// WriteCSV writes the report with one column per row criterion, one per
+// column key and a total column, followed by a line of totals.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(r.Rows)+len(r.Keys)+1)
+	for _, c := range r.Rows {
+		header = append(header, c.Label())
+	}
+	if r.Columns != "" {
+		header = append(header, r.Keys...)
+	}
+	header = append(header, "Total")
+	cw.Write(header)
+	for _, line := range r.Lines {
+		record := append([]string(nil), line.Keys...)
+		record = append(record, r.cells(line.Hours)...)
+		record = append(record, hours(line.Total))
+		cw.Write(record)
+	}
+	record := make([]string, len(r.Rows))
+	if len(record) > 0 {
+		record[0] = "Total"
+	}
+	record = append(record, r.cells(r.Totals)...)
+	record = append(record, hours(r.Total))
+	cw.Write(record)
+	cw.Flush()
+	return cw.Error()
+}
+
+// cells returns the hours of each column key, empty when there are none.
+// Without a column criterion the total column is enough.
+func (r *Report) cells(h map[string]float64) []string {
+	if r.Columns == "" {
+		return nil
+	}
+	cells := make([]string, len(r.Keys))
+	for i, key := range r.Keys {
+		if v, ok := h[key]; ok {
+			cells[i] = hours(v)
+		}
+	}
+	return cells
+}
+
+func hours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 2, 64)
+}
+
+// WriteJSON writes the report as a JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
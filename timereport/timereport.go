@@ -0,0 +1,252 @@
+// Package timereport aggregates spent time into pivot tables, like the
+// time report page of Redmine.
+package timereport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/woli/redmine"
+)
+
+// Criterion is a dimension the hours are grouped by.
+type Criterion string
+
+const (
+	Project  Criterion = "project"
+	User     Criterion = "user"
+	Activity Criterion = "activity"
+	Issue    Criterion = "issue"
+	Tracker  Criterion = "tracker"
+	Version  Criterion = "version"
+	Week     Criterion = "week"
+	Month    Criterion = "month"
+)
+
+const customFieldPrefix = "cf:"
+
+// CustomField groups by the value of the named custom field of the time
+// entries, or of their issues when the entries do not have it.
+func CustomField(name string) Criterion {
+	return Criterion(customFieldPrefix + name)
+}
+
+// Label is the name of the criterion used in the exported headers.
+func (c Criterion) Label() string {
+	if strings.HasPrefix(string(c), customFieldPrefix) {
+		return strings.TrimPrefix(string(c), customFieldPrefix)
+	}
+	return string(c)
+}
+
+// needsIssue tells whether the criterion requires the issues of the entries.
+func (c Criterion) needsIssue() bool {
+	return c == Issue || c == Tracker || c == Version ||
+		strings.HasPrefix(string(c), customFieldPrefix)
+}
+
+// None is the key of the entries without a value for a criterion.
+const None = "[none]"
+
+//-------------------------------------------------------------------------
+// report
+//-------------------------------------------------------------------------
+
+// Report is a pivot table of hours. Each line holds the hours of one
+// combination of the row criteria, split over the keys of the column
+// criterion; without a column criterion there is a single "" column.
+type Report struct {
+	Rows    []Criterion        `json:"rows"`
+	Columns Criterion          `json:"columns,omitempty"`
+	Keys    []string           `json:"keys"`
+	Lines   []*Line            `json:"lines"`
+	Totals  map[string]float64 `json:"totals"`
+	Total   float64            `json:"total"`
+}
+
+type Line struct {
+	Keys  []string           `json:"keys"`
+	Hours map[string]float64 `json:"hours"`
+	Total float64            `json:"total"`
+}
+
+// Build lists the time entries matching list, page by page, and aggregates
+// them. The issues of the entries are fetched when a criterion needs them.
+func Build(s *redmine.Service, list *redmine.TimeEntriesListCall, rows []Criterion, columns Criterion) (*Report, error) {
+	var entries []*redmine.TimeEntry
+	err := list.Pages(func(feed *redmine.TimeEntryFeed) error {
+		entries = append(entries, feed.TimeEntries...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var issues map[int]*redmine.Issue
+	for _, c := range append([]Criterion{columns}, rows...) {
+		if c.needsIssue() {
+			issues, err = loadIssues(s, entries)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return Aggregate(entries, issues, rows, columns), nil
+}
+
+// loadIssues fetches the issues the entries are logged on, whatever their
+// status. Issues that are not visible are left out.
+func loadIssues(s *redmine.Service, entries []*redmine.TimeEntry) (map[int]*redmine.Issue, error) {
+	seen := make(map[int]bool)
+	var ids []string
+	for _, entry := range entries {
+		if entry.Issue != nil && !seen[entry.Issue.Id] {
+			seen[entry.Issue.Id] = true
+			ids = append(ids, strconv.Itoa(entry.Issue.Id))
+		}
+	}
+	issues := make(map[int]*redmine.Issue)
+	const chunk = 50
+	for start := 0; start < len(ids); start += chunk {
+		end := start + chunk
+		if end > len(ids) {
+			end = len(ids)
+		}
+		err := s.Issues.List().
+			Filter("issue_id", strings.Join(ids[start:end], ",")).
+			Filter("status_id", "*").
+			Pages(func(feed *redmine.IssueFeed) error {
+				for _, issue := range feed.Issues {
+					issues[issue.Id] = issue
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// Aggregate groups the hours of entries. issues maps the ids of the issues
+// of the entries to the issues, and may be nil when no criterion needs them.
+// Lines and column keys are sorted.
+func Aggregate(entries []*redmine.TimeEntry, issues map[int]*redmine.Issue, rows []Criterion, columns Criterion) *Report {
+	r := &Report{
+		Rows:    rows,
+		Columns: columns,
+		Totals:  make(map[string]float64),
+	}
+	lines := make(map[string]*Line)
+	for _, entry := range entries {
+		var issue *redmine.Issue
+		if entry.Issue != nil {
+			issue = issues[entry.Issue.Id]
+		}
+		keys := make([]string, len(rows))
+		for i, c := range rows {
+			keys[i] = key(c, entry, issue)
+		}
+		column := ""
+		if columns != "" {
+			column = key(columns, entry, issue)
+		}
+		id := strings.Join(keys, "\x00")
+		line, ok := lines[id]
+		if !ok {
+			line = &Line{Keys: keys, Hours: make(map[string]float64)}
+			lines[id] = line
+			r.Lines = append(r.Lines, line)
+		}
+		if _, ok := r.Totals[column]; !ok {
+			r.Keys = append(r.Keys, column)
+		}
+		line.Hours[column] += entry.Hours
+		line.Total += entry.Hours
+		r.Totals[column] += entry.Hours
+		r.Total += entry.Hours
+	}
+	sort.Strings(r.Keys)
+	sort.Slice(r.Lines, func(i, j int) bool {
+		a, b := r.Lines[i].Keys, r.Lines[j].Keys
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return r
+}
+
+func key(c Criterion, entry *redmine.TimeEntry, issue *redmine.Issue) string {
+	name := func(n *redmine.Name) string {
+		if n == nil || n.Name == "" {
+			return None
+		}
+		return n.Name
+	}
+	switch c {
+	case Project:
+		return name(entry.Project)
+	case User:
+		return name(entry.User)
+	case Activity:
+		return name(entry.Activity)
+	case Issue:
+		if entry.Issue == nil {
+			return None
+		}
+		if issue == nil {
+			return fmt.Sprintf("#%v", entry.Issue.Id)
+		}
+		return fmt.Sprintf("#%v %v", issue.Id, issue.Subject)
+	case Tracker:
+		if issue == nil {
+			return None
+		}
+		return name(issue.Tracker)
+	case Version:
+		if issue == nil {
+			return None
+		}
+		return name(issue.FixedVersion)
+	case Week, Month:
+		day, err := time.Parse(redmine.DateLayout, entry.SpentOn)
+		if err != nil {
+			return None
+		}
+		if c == Month {
+			return day.Format("2006-01")
+		}
+		year, week := day.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	cfName := c.Label()
+	fields := entry.CustomFields
+	if !hasCustomField(fields, cfName) && issue != nil {
+		fields = issue.CustomFields
+	}
+	for _, cf := range fields {
+		if cf.Name == cfName {
+			values := cf.Strings()
+			if len(values) == 0 {
+				return None
+			}
+			return strings.Join(values, ", ")
+		}
+	}
+	return None
+}
+
+func hasCustomField(fields []*redmine.CustomField, name string) bool {
+	for _, cf := range fields {
+		if cf.Name == name {
+			return true
+		}
+	}
+	return false
+}
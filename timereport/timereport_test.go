@@ -0,0 +1,129 @@
+package timereport
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/woli/redmine"
+)
+
+func entry(hours float64, spentOn, user string, issueId int, cfs ...*redmine.CustomField) *redmine.TimeEntry {
+	e := &redmine.TimeEntry{
+		Hours:        hours,
+		SpentOn:      spentOn,
+		User:         &redmine.Name{Name: user},
+		Project:      &redmine.Name{Id: 1, Name: "Web"},
+		Activity:     &redmine.Name{Id: 9, Name: "Development"},
+		CustomFields: cfs,
+	}
+	if issueId > 0 {
+		e.Issue = &redmine.Name{Id: issueId}
+	}
+	return e
+}
+
+var issues = map[int]*redmine.Issue{
+	1: {
+		Id:           1,
+		Subject:      "Login",
+		Tracker:      &redmine.Name{Name: "Feature"},
+		FixedVersion: &redmine.Name{Name: "1.0"},
+		CustomFields: []*redmine.CustomField{{Name: "Client", Value: "ACME"}},
+	},
+	2: {
+		Id:      2,
+		Subject: "Crash",
+		Tracker: &redmine.Name{Name: "Bug"},
+	},
+}
+
+func TestAggregate(t *testing.T) {
+	entries := []*redmine.TimeEntry{
+		entry(2, "2024-01-31", "bob", 1),
+		entry(1.5, "2024-02-01", "al", 2),
+		entry(3, "2024-02-05", "bob", 1, &redmine.CustomField{Name: "Client", Value: "Globex"}),
+		entry(0.5, "2024-02-05", "bob", 0),
+	}
+	tests := []struct {
+		name    string
+		rows    []Criterion
+		columns Criterion
+		keys    []string
+		lines   [][]string
+		totals  []float64
+	}{
+		{
+			name:    "tracker by month",
+			rows:    []Criterion{Tracker},
+			columns: Month,
+			keys:    []string{"2024-01", "2024-02"},
+			lines:   [][]string{{"Bug"}, {"Feature"}, {None}},
+			totals:  []float64{1.5, 5, 0.5},
+		},
+		{
+			name:   "user and version",
+			rows:   []Criterion{User, Version},
+			keys:   []string{""},
+			lines:  [][]string{{"al", None}, {"bob", "1.0"}, {"bob", None}},
+			totals: []float64{1.5, 5, 0.5},
+		},
+		{
+			name:    "issue by week",
+			rows:    []Criterion{Issue},
+			columns: Week,
+			keys:    []string{"2024-W05", "2024-W06"},
+			lines:   [][]string{{"#1 Login"}, {"#2 Crash"}, {None}},
+			totals:  []float64{5, 1.5, 0.5},
+		},
+		{
+			// the entry value wins over the issue one
+			name:   "custom field",
+			rows:   []Criterion{CustomField("Client")},
+			keys:   []string{""},
+			lines:  [][]string{{"ACME"}, {"Globex"}, {None}},
+			totals: []float64{2, 3, 2},
+		},
+	}
+	for _, tt := range tests {
+		r := Aggregate(entries, issues, tt.rows, tt.columns)
+		if !reflect.DeepEqual(r.Keys, tt.keys) {
+			t.Errorf("%v: Keys = %v, want %v", tt.name, r.Keys, tt.keys)
+		}
+		var lines [][]string
+		var totals []float64
+		for _, line := range r.Lines {
+			lines = append(lines, line.Keys)
+			totals = append(totals, line.Total)
+		}
+		if !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("%v: lines = %v, want %v", tt.name, lines, tt.lines)
+		}
+		if !reflect.DeepEqual(totals, tt.totals) {
+			t.Errorf("%v: line totals = %v, want %v", tt.name, totals, tt.totals)
+		}
+		if r.Total != 7 {
+			t.Errorf("%v: Total = %v, want 7", tt.name, r.Total)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	entries := []*redmine.TimeEntry{
+		entry(2, "2024-01-31", "bob", 1),
+		entry(1.5, "2024-02-01", "al", 2),
+		entry(3, "2024-02-05", "bob", 1),
+	}
+	b := new(bytes.Buffer)
+	err := Aggregate(entries, issues, []Criterion{User}, Month).WriteCSV(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "user,2024-01,2024-02,Total\n" +
+		"al,,1.50,1.50\n" +
+		"bob,2.00,3.00,5.00\n" +
+		"Total,2.00,4.50,6.50\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", b.String(), want)
+	}
+}
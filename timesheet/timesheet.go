@@ -0,0 +1,322 @@
+// Package timesheet imports time entries from CSV timesheets.
+package timesheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/woli/redmine"
+	"github.com/woli/redmine/lookup"
+)
+
+// Columns holds the CSV headers mapped to the time entry fields. Headers
+// are matched case-insensitively. Comments is optional, and one of
+// Project and Issue must be set on every row. Projects are given by
+// identifier or id, users by login.
+type Columns struct {
+	Date     string
+	Hours    string
+	User     string
+	Activity string
+	Project  string
+	Issue    string
+	Comments string
+}
+
+var DefaultColumns = Columns{
+	Date:     "date",
+	Hours:    "hours",
+	User:     "user",
+	Activity: "activity",
+	Project:  "project",
+	Issue:    "issue",
+	Comments: "comments",
+}
+
+// RowError is an error found on a row of the timesheet. Rows are numbered
+// from 1, the header being row 1.
+type RowError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %v: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %v, %v: %v", e.Row, e.Column, e.Err)
+}
+
+type Result struct {
+	// Created holds the entries created, or the ones that would be in a
+	// dry run.
+	Created []*redmine.TimeEntry
+	// Skipped counts the rows already imported.
+	Skipped int
+	Errors  []*RowError
+}
+
+//-------------------------------------------------------------------------
+// importer
+//-------------------------------------------------------------------------
+
+type Importer struct {
+	s       *redmine.Service
+	columns Columns
+	dryRun  bool
+
+	names    *lookup.Lookup
+	issues   map[int]error
+	existing map[string][]*redmine.TimeEntry
+}
+
+func New(s *redmine.Service) *Importer {
+	return &Importer{
+		s:        s,
+		columns:  DefaultColumns,
+		names:    lookup.New(s),
+		issues:   make(map[int]error),
+		existing: make(map[string][]*redmine.TimeEntry),
+	}
+}
+
+func (im *Importer) Columns(columns Columns) *Importer {
+	im.columns = columns
+	return im
+}
+
+// DryRun validates the rows and reports the entries to create without
+// creating them.
+func (im *Importer) DryRun(dryRun bool) *Importer {
+	im.dryRun = dryRun
+	return im
+}
+
+// Import reads a timesheet and creates its entries. All the rows are
+// validated first, and nothing is created when any of them is invalid.
+//
+// Rows matching an entry already logged by the user on that day, with the
+// same project, issue, activity, hours and comments, are skipped, so that
+// importing the same file twice does not duplicate entries.
+func (im *Importer) Import(r io.Reader) (*Result, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	index, err := im.index(header)
+	if err != nil {
+		return nil, err
+	}
+	ret := new(Result)
+	var entries []*redmine.TimeEntry
+	var rows []int
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		entry, errs := im.parse(row, field)
+		if len(errs) > 0 {
+			ret.Errors = append(ret.Errors, errs...)
+			continue
+		}
+		entries = append(entries, entry)
+		rows = append(rows, row)
+	}
+	if len(ret.Errors) > 0 {
+		return ret, nil
+	}
+	for i, entry := range entries {
+		duplicate, err := im.duplicate(entry)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate {
+			ret.Skipped++
+			continue
+		}
+		if im.dryRun {
+			ret.Created = append(ret.Created, entry)
+			continue
+		}
+		created, err := im.s.TimeEntries.Insert(entry).Do()
+		if err != nil {
+			ret.Errors = append(ret.Errors, &RowError{Row: rows[i], Err: err})
+			continue
+		}
+		ret.Created = append(ret.Created, created)
+	}
+	return ret, nil
+}
+
+// index maps the configured columns to their position in the header.
+func (im *Importer) index(header []string) (map[string]int, error) {
+	positions := make(map[string]int)
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	index := make(map[string]int)
+	c := im.columns
+	for _, column := range []string{c.Date, c.Hours, c.User, c.Activity, c.Project, c.Issue, c.Comments} {
+		if column == "" {
+			continue
+		}
+		if i, ok := positions[strings.ToLower(column)]; ok {
+			index[column] = i
+		}
+	}
+	for _, column := range []string{c.Date, c.Hours, c.User, c.Activity} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+	_, project := index[c.Project]
+	_, issue := index[c.Issue]
+	if !project && !issue {
+		return nil, fmt.Errorf("missing column %q or %q", c.Project, c.Issue)
+	}
+	return index, nil
+}
+
+// parse validates a row and resolves its names to ids.
+func (im *Importer) parse(row int, field func(string) string) (*redmine.TimeEntry, []*RowError) {
+	var errs []*RowError
+	fail := func(column string, err error) {
+		errs = append(errs, &RowError{Row: row, Column: column, Err: err})
+	}
+	c := im.columns
+	entry := &redmine.TimeEntry{Comments: field(c.Comments)}
+
+	day, err := time.Parse(redmine.DateLayout, field(c.Date))
+	if err != nil {
+		fail(c.Date, fmt.Errorf("invalid date %q", field(c.Date)))
+	} else {
+		entry.SpentOn = day.Format(redmine.DateLayout)
+	}
+
+	hours, err := strconv.ParseFloat(strings.Replace(field(c.Hours), ",", ".", 1), 64)
+	if err != nil || hours <= 0 || hours > 24 {
+		fail(c.Hours, fmt.Errorf("invalid hours %q", field(c.Hours)))
+	} else {
+		entry.Hours = hours
+	}
+
+	if login := field(c.User); login == "" {
+		fail(c.User, errors.New("user required"))
+	} else if userId, err := im.names.User(login); err != nil {
+		fail(c.User, err)
+	} else {
+		entry.User = &redmine.Name{Id: userId}
+	}
+
+	activityId, err := im.names.Activity(field(c.Activity))
+	if err != nil {
+		fail(c.Activity, err)
+	} else {
+		entry.Activity = &redmine.Name{Id: activityId}
+	}
+
+	if identifier := field(c.Project); identifier != "" {
+		projectId, err := im.names.Project(identifier)
+		if err != nil {
+			fail(c.Project, err)
+		} else {
+			entry.Project = &redmine.Name{Id: projectId}
+		}
+	}
+
+	if value := strings.TrimPrefix(field(c.Issue), "#"); value != "" {
+		issueId, err := strconv.Atoi(value)
+		if err == nil {
+			err = im.issue(issueId)
+		} else {
+			err = fmt.Errorf("invalid issue %q", value)
+		}
+		if err != nil {
+			fail(c.Issue, err)
+		} else {
+			entry.Issue = &redmine.Name{Id: issueId}
+		}
+	}
+	if entry.Project == nil && entry.Issue == nil && len(errs) == 0 {
+		fail("", errors.New("project or issue required"))
+	}
+	return entry, errs
+}
+
+//-------------------------------------------------------------------------
+// lookups
+//-------------------------------------------------------------------------
+
+func (im *Importer) issue(issueId int) error {
+	if err, ok := im.issues[issueId]; ok {
+		return err
+	}
+	_, err := im.s.Issues.Get(issueId).Do()
+	if err != nil {
+		err = fmt.Errorf("unknown issue #%v: %v", issueId, err)
+	}
+	im.issues[issueId] = err
+	return err
+}
+
+// duplicate tells whether the entry was already logged. Each existing
+// entry matches a single row, so identical rows of a file are all created
+// the first time and all skipped afterwards.
+func (im *Importer) duplicate(entry *redmine.TimeEntry) (bool, error) {
+	key := fmt.Sprintf("%v %v", entry.User.Id, entry.SpentOn)
+	existing, ok := im.existing[key]
+	if !ok {
+		day, _ := time.Parse(redmine.DateLayout, entry.SpentOn)
+		err := im.s.TimeEntries.List().UserId(entry.User.Id).SpentOn(day).Pages(func(feed *redmine.TimeEntryFeed) error {
+			existing = append(existing, feed.TimeEntries...)
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	for i, e := range existing {
+		if same(e, entry) {
+			im.existing[key] = append(existing[:i:i], existing[i+1:]...)
+			return true, nil
+		}
+	}
+	im.existing[key] = existing
+	return false, nil
+}
+
+func same(e, entry *redmine.TimeEntry) bool {
+	id := func(n *redmine.Name) int {
+		if n == nil {
+			return 0
+		}
+		return n.Id
+	}
+	if entry.Project != nil && id(e.Project) != entry.Project.Id {
+		return false
+	}
+	return id(e.Issue) == id(entry.Issue) &&
+		id(e.Activity) == id(entry.Activity) &&
+		math.Abs(e.Hours-entry.Hours) < 0.005 &&
+		strings.TrimSpace(e.Comments) == entry.Comments
+}
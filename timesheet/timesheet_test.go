@@ -0,0 +1,202 @@
+package timesheet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/woli/redmine"
+)
+
+// server answers the lookups of the importer from canned responses.
+func server(t *testing.T, timeEntries string) (*Importer, func()) {
+	responses := map[string]string{
+		"/users.json":                              `{"users": [{"id": 3, "login": "bob"}]}`,
+		"/projects/web.json":                       `{"project": {"id": 1, "identifier": "web"}}`,
+		"/projects/1.json":                         `{"project": {"id": 1, "identifier": "web"}}`,
+		"/issues/123.json":                         `{"issue": {"id": 123}}`,
+		"/time_entries.json":                       timeEntries,
+		"/enumerations/time_entry_activities.json": `{"time_entry_activities": [{"id": 9, "name": "Development"}]}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	s, err := redmine.New(ts.URL+"/", &redmine.ApiKeyAuth{ApiKey: "key"}, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(s), ts.Close
+}
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		err    string
+	}{
+		{"all", "Date, Hours, User, Activity, Project, Issue, Comments", ""},
+		{"project only", "date,hours,user,activity,project", ""},
+		{"issue only", "date,hours,user,activity,issue", ""},
+		{"missing hours", "date,user,activity,project", `missing column "hours"`},
+		{"no project or issue", "date,hours,user,activity,comments", `missing column "project" or "issue"`},
+	}
+	for _, tt := range tests {
+		_, err := New(nil).index(strings.Split(tt.header, ","))
+		if tt.err == "" && err != nil {
+			t.Errorf("%v: unexpected error %v", tt.name, err)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%v: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	im, stop := server(t, "")
+	defer stop()
+	tests := []struct {
+		name    string
+		row     map[string]string
+		hours   float64
+		project int
+		issue   int
+		errs    []string
+	}{
+		{
+			name:    "project",
+			row:     map[string]string{"date": "2024-02-05", "hours": "1.5", "user": "Bob", "activity": "development", "project": "web"},
+			hours:   1.5,
+			project: 1,
+		},
+		{
+			name:    "project id",
+			row:     map[string]string{"date": "2024-02-05", "hours": "2", "user": "bob", "activity": "Development", "project": "1"},
+			hours:   2,
+			project: 1,
+		},
+		{
+			name:  "comma decimal and issue",
+			row:   map[string]string{"date": "2024-02-05", "hours": "0,25", "user": "bob", "activity": "Development", "issue": "#123"},
+			hours: 0.25,
+			issue: 123,
+		},
+		{
+			name: "hours bounds",
+			row:  map[string]string{"date": "2024-02-05", "hours": "25", "user": "bob", "activity": "Development", "issue": "123"},
+			errs: []string{`row 2, hours: invalid hours "25"`},
+		},
+		{
+			name: "zero hours",
+			row:  map[string]string{"date": "2024-02-05", "hours": "0", "user": "bob", "activity": "Development", "issue": "123"},
+			errs: []string{`row 2, hours: invalid hours "0"`},
+		},
+		{
+			name: "unknown names",
+			row:  map[string]string{"date": "5/2/2024", "hours": "1", "user": "al", "activity": "Design", "issue": "#12x"},
+			errs: []string{
+				`row 2, date: invalid date "5/2/2024"`,
+				`row 2, user: unknown user "al"`,
+				`row 2, activity: unknown activity "Design"`,
+				`row 2, issue: invalid issue "12x"`,
+			},
+		},
+		{
+			name: "no project or issue",
+			row:  map[string]string{"date": "2024-02-05", "hours": "1", "user": "bob", "activity": "Development"},
+			errs: []string{"row 2: project or issue required"},
+		},
+	}
+	for _, tt := range tests {
+		entry, errs := im.parse(2, func(column string) string { return tt.row[column] })
+		var got []string
+		for _, err := range errs {
+			got = append(got, err.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.errs, "\n") {
+			t.Errorf("%v: errors = %q, want %q", tt.name, got, tt.errs)
+			continue
+		}
+		if len(errs) > 0 {
+			continue
+		}
+		if entry.Hours != tt.hours || entry.User.Id != 3 || entry.Activity.Id != 9 || entry.SpentOn != "2024-02-05" {
+			t.Errorf("%v: entry = %+v", tt.name, entry)
+		}
+		if (entry.Project != nil && entry.Project.Id != tt.project) || (entry.Project == nil && tt.project != 0) {
+			t.Errorf("%v: Project = %+v, want %v", tt.name, entry.Project, tt.project)
+		}
+		if (entry.Issue != nil && entry.Issue.Id != tt.issue) || (entry.Issue == nil && tt.issue != 0) {
+			t.Errorf("%v: Issue = %+v, want %v", tt.name, entry.Issue, tt.issue)
+		}
+	}
+}
+
+func timeEntry(project, issue int, hours float64, comments string) *redmine.TimeEntry {
+	e := &redmine.TimeEntry{
+		Project:  &redmine.Name{Id: project},
+		User:     &redmine.Name{Id: 3},
+		Activity: &redmine.Name{Id: 9},
+		SpentOn:  "2024-02-05",
+		Hours:    hours,
+		Comments: comments,
+	}
+	if issue > 0 {
+		e.Issue = &redmine.Name{Id: issue}
+	}
+	return e
+}
+
+func TestSame(t *testing.T) {
+	existing := timeEntry(1, 123, 1.5, "review ")
+	tests := []struct {
+		name  string
+		entry *redmine.TimeEntry
+		want  bool
+	}{
+		{"same", timeEntry(1, 123, 1.5, "review"), true},
+		{"no project in the row", &redmine.TimeEntry{Issue: &redmine.Name{Id: 123}, Activity: &redmine.Name{Id: 9}, Hours: 1.5, Comments: "review"}, true},
+		{"rounding", timeEntry(1, 123, 1.501, "review"), true},
+		{"other project", timeEntry(2, 123, 1.5, "review"), false},
+		{"other issue", timeEntry(1, 124, 1.5, "review"), false},
+		{"other hours", timeEntry(1, 123, 2, "review"), false},
+		{"other comments", timeEntry(1, 123, 1.5, "fix"), false},
+	}
+	for _, tt := range tests {
+		if got := same(existing, tt.entry); got != tt.want {
+			t.Errorf("%v: same() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	// two identical entries were logged, a third identical row is new
+	im, stop := server(t, `{"time_entries": [
+		{"id": 1, "project": {"id": 1}, "issue": {"id": 123}, "user": {"id": 3}, "activity": {"id": 9}, "hours": 2, "comments": "review", "spent_on": "2024-02-05"},
+		{"id": 2, "project": {"id": 1}, "issue": {"id": 123}, "user": {"id": 3}, "activity": {"id": 9}, "hours": 2, "comments": "review", "spent_on": "2024-02-05"},
+		{"id": 3, "project": {"id": 1}, "user": {"id": 3}, "activity": {"id": 9}, "hours": 1, "comments": "", "spent_on": "2024-02-05"}
+	], "total_count": 3, "offset": 0, "limit": 100}`)
+	defer stop()
+	rows := []*redmine.TimeEntry{
+		timeEntry(1, 123, 2, "review"),
+		timeEntry(1, 0, 1, ""),
+		timeEntry(1, 123, 2, "review"),
+		timeEntry(1, 123, 2, "review"),
+		timeEntry(1, 0, 1, ""),
+	}
+	want := []bool{true, true, true, false, false}
+	for i, entry := range rows {
+		got, err := im.duplicate(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want[i] {
+			t.Errorf("row %v: duplicate() = %v, want %v", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package redmine
+
+import (
+	"strconv"
+	"strings"
+)
+
+//-------------------------------------------------------------------------
+// issue tree
+//-------------------------------------------------------------------------
+
+// IssueNode is an issue of a subtask hierarchy. The hours, done ratio and
+// counts are rolled up over the node and all its descendants. The hours
+// sum the own estimate and spent time of every issue, as reported by
+// redmine 3.3 and later, where a parent no longer includes its subtasks.
+type IssueNode struct {
+	Issue          *Issue
+	Children       []*IssueNode
+	EstimatedHours float64
+	SpentHours     float64
+	// DoneRatio is the done ratio of the leaves weighted by their estimated
+	// hours, or their plain average when none is estimated.
+	DoneRatio float64
+	Open      int
+	Closed    int
+}
+
+type IssuesTreeCall struct {
+	s      *Service
+	rootId int
+}
+
+func (r *IssuesService) Tree(rootId int) *IssuesTreeCall {
+	return &IssuesTreeCall{r.s, rootId}
+}
+
+// Do fetches the hierarchy one level at a time, listing the children of a
+// whole level with a single parent_id filter.
+func (c *IssuesTreeCall) Do() (*IssueNode, error) {
+	statuses, err := c.s.IssueStatuses.List().Do()
+	if err != nil {
+		return nil, err
+	}
+	closed := make(map[int]bool)
+	for _, status := range statuses {
+		closed[status.Id] = status.IsClosed
+	}
+	root, err := c.s.Issues.Get(c.rootId).Do()
+	if err != nil {
+		return nil, err
+	}
+	rootNode := &IssueNode{Issue: root}
+	level := map[int]*IssueNode{root.Id: rootNode}
+	for len(level) > 0 {
+		ids := make([]string, 0, len(level))
+		for id := range level {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		next := make(map[int]*IssueNode)
+		const chunk = 50
+		for start := 0; start < len(ids); start += chunk {
+			end := start + chunk
+			if end > len(ids) {
+				end = len(ids)
+			}
+			children, err := c.s.Issues.listAll(map[string]string{
+				"parent_id": strings.Join(ids[start:end], ","),
+				"status_id": "*",
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				if child.Parent == nil {
+					continue
+				}
+				parent, ok := level[child.Parent.Id]
+				if !ok {
+					continue
+				}
+				node := &IssueNode{Issue: child}
+				parent.Children = append(parent.Children, node)
+				next[child.Id] = node
+			}
+		}
+		level = next
+	}
+	rootNode.rollUp(closed)
+	return rootNode, nil
+}
+
+// rollUp fills the totals of the node and returns the weight and weighted
+// done ratio of its leaves.
+func (n *IssueNode) rollUp(closed map[int]bool) (leaves, estimated, doneEstimated, done float64) {
+	n.EstimatedHours = n.Issue.EstimatedHours
+	n.SpentHours = n.Issue.SpentHours
+	n.Open, n.Closed = 0, 0
+	if n.Issue.Status != nil && closed[n.Issue.Status.Id] {
+		n.Closed++
+	} else {
+		n.Open++
+	}
+	if len(n.Children) == 0 {
+		leaves = 1
+		estimated = n.Issue.EstimatedHours
+		doneEstimated = n.Issue.EstimatedHours * float64(n.Issue.DoneRatio)
+		done = float64(n.Issue.DoneRatio)
+	}
+	for _, child := range n.Children {
+		l, e, de, d := child.rollUp(closed)
+		leaves += l
+		estimated += e
+		doneEstimated += de
+		done += d
+		n.EstimatedHours += child.EstimatedHours
+		n.SpentHours += child.SpentHours
+		n.Open += child.Open
+		n.Closed += child.Closed
+	}
+	if estimated > 0 {
+		n.DoneRatio = doneEstimated / estimated
+	} else if leaves > 0 {
+		n.DoneRatio = done / leaves
+	}
+	return leaves, estimated, doneEstimated, done
+}
+
+// Walk calls f for the node and its descendants, depth first.
+func (n *IssueNode) Walk(f func(node *IssueNode, depth int)) {
+	n.walk(f, 0)
+}
+
+func (n *IssueNode) walk(f func(node *IssueNode, depth int), depth int) {
+	f(n, depth)
+	for _, child := range n.Children {
+		child.walk(f, depth+1)
+	}
+}
@@ -0,0 +1,80 @@
+package redmine
+
+import "testing"
+
+func node(id, status int, estimated, spent float64, done int, children ...*IssueNode) *IssueNode {
+	return &IssueNode{
+		Issue: &Issue{
+			Id:             id,
+			Status:         &Name{Id: status},
+			EstimatedHours: estimated,
+			SpentHours:     spent,
+			DoneRatio:      done,
+		},
+		Children: children,
+	}
+}
+
+func TestRollUp(t *testing.T) {
+	closed := map[int]bool{5: true}
+	tests := []struct {
+		name      string
+		root      *IssueNode
+		estimated float64
+		spent     float64
+		doneRatio float64
+		open      int
+		closed    int
+	}{
+		{
+			name:      "leaf",
+			root:      node(1, 1, 4, 1.5, 50),
+			estimated: 4,
+			spent:     1.5,
+			doneRatio: 50,
+			open:      1,
+		},
+		{
+			// the parent hours are its own and add to the subtasks
+			name: "own hours of parents",
+			root: node(1, 1, 2, 1, 0,
+				node(2, 5, 6, 3, 100),
+				node(3, 1, 2, 0.5, 0,
+					node(4, 1, 2, 2, 50),
+				),
+			),
+			estimated: 12,
+			spent:     6.5,
+			doneRatio: 87.5,
+			open:      3,
+			closed:    1,
+		},
+		{
+			name: "no estimates",
+			root: node(1, 1, 0, 0, 0,
+				node(2, 1, 0, 1, 20),
+				node(3, 5, 0, 2, 80),
+			),
+			spent:     3,
+			doneRatio: 50,
+			open:      2,
+			closed:    1,
+		},
+	}
+	for _, tt := range tests {
+		tt.root.rollUp(closed)
+		n := tt.root
+		if n.EstimatedHours != tt.estimated {
+			t.Errorf("%v: EstimatedHours = %v, want %v", tt.name, n.EstimatedHours, tt.estimated)
+		}
+		if n.SpentHours != tt.spent {
+			t.Errorf("%v: SpentHours = %v, want %v", tt.name, n.SpentHours, tt.spent)
+		}
+		if n.DoneRatio != tt.doneRatio {
+			t.Errorf("%v: DoneRatio = %v, want %v", tt.name, n.DoneRatio, tt.doneRatio)
+		}
+		if n.Open != tt.open || n.Closed != tt.closed {
+			t.Errorf("%v: Open, Closed = %v, %v, want %v, %v", tt.name, n.Open, n.Closed, tt.open, tt.closed)
+		}
+	}
+}